@@ -0,0 +1,262 @@
+package genmap
+
+import (
+	"hash/maphash"
+	"math"
+	"reflect"
+	"sync"
+)
+
+// autoPlan holds the hash and equality functions of a type, built once by
+// walking the type with reflection.
+type autoPlan struct {
+	hash     func(v reflect.Value) uint64
+	equal    func(a, b reflect.Value) bool
+	typeHash uint64 // distinguishes dynamic types in interfaces
+}
+
+var (
+	autoPlans   sync.Map // reflect.Type -> *autoPlan
+	autoPlansMu sync.Mutex
+	autoSeed    = maphash.MakeSeed()
+)
+
+// NewAutoHasher returns a hash function for any type T, consistent with the
+// equality function returned by NewAutoEqual[T].
+//
+// Structs (including unexported fields), arrays, slices, maps (independently
+// of the iteration order), pointers (hashing the pointed value) and interfaces
+// (hashing the dynamic value) are supported. Recursive types are supported,
+// but cyclic values are not.
+//
+// The hash function relies on reflection: for hot paths, prefer a hash
+// function written for the key type, combining NewHasher, CombineHash and
+// CombineHashes.
+func NewAutoHasher[T any]() func(T) uint64 {
+	plan := autoPlanFor(reflect.TypeFor[T]())
+	return func(v T) uint64 {
+		return plan.hash(reflect.ValueOf(&v).Elem())
+	}
+}
+
+// NewAutoEqual returns an equality function for any type T, consistent with
+// the hash function returned by NewAutoHasher[T].
+//
+// The comparison is deep, like reflect.DeepEqual, except that nil and empty
+// slices (or maps) are equal, and that +0 and -0 floats are equal.
+// Functions are equal only if both are nil.
+func NewAutoEqual[T any]() func(a, b T) bool {
+	plan := autoPlanFor(reflect.TypeFor[T]())
+	return func(a, b T) bool {
+		return plan.equal(reflect.ValueOf(&a).Elem(), reflect.ValueOf(&b).Elem())
+	}
+}
+
+// autoPlanFor returns the cached plan of the given type, building it if needed.
+func autoPlanFor(t reflect.Type) *autoPlan {
+	if plan, ok := autoPlans.Load(t); ok {
+		return plan.(*autoPlan)
+	}
+	autoPlansMu.Lock()
+	defer autoPlansMu.Unlock()
+	building := make(map[reflect.Type]*autoPlan)
+	plan := buildAutoPlan(t, building)
+	for t, plan := range building {
+		autoPlans.Store(t, plan)
+	}
+	return plan
+}
+
+// buildAutoPlan builds the plan of the given type. The plans being built are
+// registered in building before their functions are set, so that recursive
+// types refer to the plan through its pointer.
+func buildAutoPlan(t reflect.Type, building map[reflect.Type]*autoPlan) *autoPlan {
+	if plan, ok := autoPlans.Load(t); ok {
+		return plan.(*autoPlan)
+	}
+	if plan, ok := building[t]; ok {
+		return plan
+	}
+	plan := &autoPlan{typeHash: maphash.String(autoSeed, t.String())}
+	building[t] = plan
+
+	switch t.Kind() {
+	case reflect.Bool:
+		plan.hash = func(v reflect.Value) uint64 {
+			if v.Bool() {
+				return mix64(1)
+			}
+			return mix64(0)
+		}
+		plan.equal = func(a, b reflect.Value) bool { return a.Bool() == b.Bool() }
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		plan.hash = func(v reflect.Value) uint64 { return mix64(uint64(v.Int())) }
+		plan.equal = func(a, b reflect.Value) bool { return a.Int() == b.Int() }
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		plan.hash = func(v reflect.Value) uint64 { return mix64(v.Uint()) }
+		plan.equal = func(a, b reflect.Value) bool { return a.Uint() == b.Uint() }
+	case reflect.Float32, reflect.Float64:
+		plan.hash = func(v reflect.Value) uint64 { return hashFloat(v.Float()) }
+		plan.equal = func(a, b reflect.Value) bool { return a.Float() == b.Float() }
+	case reflect.Complex64, reflect.Complex128:
+		plan.hash = func(v reflect.Value) uint64 {
+			c := v.Complex()
+			return CombineHash(hashFloat(real(c)), hashFloat(imag(c)))
+		}
+		plan.equal = func(a, b reflect.Value) bool { return a.Complex() == b.Complex() }
+	case reflect.String:
+		plan.hash = func(v reflect.Value) uint64 { return maphash.String(autoSeed, v.String()) }
+		plan.equal = func(a, b reflect.Value) bool { return a.String() == b.String() }
+	case reflect.Chan, reflect.UnsafePointer:
+		// identity
+		plan.hash = func(v reflect.Value) uint64 { return mix64(uint64(v.Pointer())) }
+		plan.equal = func(a, b reflect.Value) bool { return a.Pointer() == b.Pointer() }
+	case reflect.Func:
+		plan.hash = func(v reflect.Value) uint64 { return 0 }
+		plan.equal = func(a, b reflect.Value) bool { return a.IsNil() && b.IsNil() }
+	case reflect.Pointer:
+		elem := buildAutoPlan(t.Elem(), building)
+		plan.hash = func(v reflect.Value) uint64 {
+			if v.IsNil() {
+				return 0
+			}
+			return elem.hash(v.Elem())
+		}
+		plan.equal = func(a, b reflect.Value) bool {
+			if a.IsNil() || b.IsNil() {
+				return a.IsNil() && b.IsNil()
+			}
+			return a.Pointer() == b.Pointer() || elem.equal(a.Elem(), b.Elem())
+		}
+	case reflect.Interface:
+		plan.hash = func(v reflect.Value) uint64 {
+			if v.IsNil() {
+				return 0
+			}
+			v = v.Elem()
+			dynamic := autoPlanFor(v.Type())
+			return CombineHash(dynamic.typeHash, dynamic.hash(v))
+		}
+		plan.equal = func(a, b reflect.Value) bool {
+			if a.IsNil() || b.IsNil() {
+				return a.IsNil() && b.IsNil()
+			}
+			a, b = a.Elem(), b.Elem()
+			return a.Type() == b.Type() && autoPlanFor(a.Type()).equal(a, b)
+		}
+	case reflect.Array:
+		elem := buildAutoPlan(t.Elem(), building)
+		plan.hash = func(v reflect.Value) uint64 {
+			h := HashSeed
+			for i := 0; i < v.Len(); i++ {
+				h = CombineHash(h, elem.hash(v.Index(i)))
+			}
+			return h
+		}
+		plan.equal = func(a, b reflect.Value) bool {
+			for i := 0; i < a.Len(); i++ {
+				if !elem.equal(a.Index(i), b.Index(i)) {
+					return false
+				}
+			}
+			return true
+		}
+	case reflect.Slice:
+		if t.Elem().Kind() == reflect.Uint8 {
+			plan.hash = func(v reflect.Value) uint64 { return maphash.Bytes(autoSeed, v.Bytes()) }
+			plan.equal = func(a, b reflect.Value) bool { return string(a.Bytes()) == string(b.Bytes()) }
+			break
+		}
+		elem := buildAutoPlan(t.Elem(), building)
+		plan.hash = func(v reflect.Value) uint64 {
+			h := HashSeed
+			for i := 0; i < v.Len(); i++ {
+				h = CombineHash(h, elem.hash(v.Index(i)))
+			}
+			return h
+		}
+		plan.equal = func(a, b reflect.Value) bool {
+			if a.Len() != b.Len() {
+				return false
+			}
+			if a.Len() == 0 || a.Pointer() == b.Pointer() {
+				return true
+			}
+			for i := 0; i < a.Len(); i++ {
+				if !elem.equal(a.Index(i), b.Index(i)) {
+					return false
+				}
+			}
+			return true
+		}
+	case reflect.Map:
+		key := buildAutoPlan(t.Key(), building)
+		elem := buildAutoPlan(t.Elem(), building)
+		plan.hash = func(v reflect.Value) uint64 {
+			// the sum does not depend on the iteration order
+			var h uint64
+			it := v.MapRange()
+			for it.Next() {
+				h += mix64(CombineHash(key.hash(it.Key()), elem.hash(it.Value())))
+			}
+			return CombineHash(HashSeed, h)
+		}
+		plan.equal = func(a, b reflect.Value) bool {
+			if a.Len() != b.Len() {
+				return false
+			}
+			if a.Len() == 0 || a.Pointer() == b.Pointer() {
+				return true
+			}
+			it := a.MapRange()
+			for it.Next() {
+				bv := b.MapIndex(it.Key())
+				if !bv.IsValid() || !elem.equal(it.Value(), bv) {
+					return false
+				}
+			}
+			return true
+		}
+	case reflect.Struct:
+		fields := make([]*autoPlan, t.NumField())
+		for i := range fields {
+			fields[i] = buildAutoPlan(t.Field(i).Type, building)
+		}
+		plan.hash = func(v reflect.Value) uint64 {
+			h := HashSeed
+			for i, field := range fields {
+				h = CombineHash(h, field.hash(v.Field(i)))
+			}
+			return h
+		}
+		plan.equal = func(a, b reflect.Value) bool {
+			for i, field := range fields {
+				if !field.equal(a.Field(i), b.Field(i)) {
+					return false
+				}
+			}
+			return true
+		}
+	default:
+		panic("genmap: unsupported type " + t.String())
+	}
+	return plan
+}
+
+// mix64 is the finalizer of MurmurHash3, spreading the bits of x.
+func mix64(x uint64) uint64 {
+	x ^= x >> 33
+	x *= 0xff51afd7ed558ccd
+	x ^= x >> 33
+	x *= 0xc4ceb9fe1a85ec53
+	x ^= x >> 33
+	return x
+}
+
+// hashFloat hashes a float so that +0 and -0 have the same hash.
+func hashFloat(f float64) uint64 {
+	if f == 0 {
+		return mix64(0)
+	}
+	return mix64(math.Float64bits(f))
+}
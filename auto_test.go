@@ -0,0 +1,123 @@
+package genmap_test
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+type autoNode struct {
+	Name     string
+	children []*autoNode
+	attrs    map[string]any
+	weight   float64
+	id       [2]int
+}
+
+func TestAutoHasherEqual(t *testing.T) {
+	hash := genmap.NewAutoHasher[autoNode]()
+	equal := genmap.NewAutoEqual[autoNode]()
+
+	newNode := func() autoNode {
+		return autoNode{
+			Name: "root",
+			children: []*autoNode{
+				{Name: "a", id: [2]int{1, 2}},
+				{Name: "b", attrs: map[string]any{"x": 1, "y": []string{"z"}}},
+			},
+			attrs:  map[string]any{"k1": 1.5, "k2": "v", "k3": nil, "k4": [1]uint8{3}},
+			weight: 0,
+		}
+	}
+	a, b := newNode(), newNode()
+	if !equal(a, b) || hash(a) != hash(b) {
+		t.Errorf("expected equal values with the same hash")
+	}
+
+	// nil and empty slices and maps, +0 and -0 are equal
+	b.children[0].attrs = map[string]any{}
+	b.children[1].children = []*autoNode{}
+	b.weight = math.Copysign(0, -1)
+	if !equal(a, b) || hash(a) != hash(b) {
+		t.Errorf("expected equal values with the same hash")
+	}
+
+	for name, modify := range map[string]func(n *autoNode){
+		"name":           func(n *autoNode) { n.Name = "other" },
+		"child name":     func(n *autoNode) { n.children[1].Name = "c" },
+		"nil child":      func(n *autoNode) { n.children[0] = nil },
+		"extra child":    func(n *autoNode) { n.children = append(n.children, &autoNode{}) },
+		"array":          func(n *autoNode) { n.children[0].id[1] = 3 },
+		"map value":      func(n *autoNode) { n.attrs["k1"] = 2.5 },
+		"map value type": func(n *autoNode) { n.attrs["k1"] = float32(1.5) },
+		"map key":        func(n *autoNode) { delete(n.attrs, "k2"); n.attrs["k5"] = "v" },
+		"nested slice":   func(n *autoNode) { n.children[1].attrs["y"] = []string{"w"} },
+	} {
+		c := newNode()
+		modify(&c)
+		if equal(a, c) || equal(c, a) {
+			t.Errorf("%s: expected different values", name)
+		}
+		if hash(a) == hash(c) {
+			t.Errorf("%s: expected different hashes", name)
+		}
+	}
+}
+
+func TestAutoHasherMapOrder(t *testing.T) {
+	hash := genmap.NewAutoHasher[map[int]string]()
+	a := make(map[int]string)
+	b := make(map[int]string)
+	for i := 0; i < 100; i++ {
+		a[i] = "v"
+		b[99-i] = "v"
+	}
+	if hash(a) != hash(b) || !genmap.NewAutoEqual[map[int]string]()(a, b) {
+		t.Errorf("expected equal maps with the same hash")
+	}
+}
+
+func TestAutoHasherInterface(t *testing.T) {
+	hash := genmap.NewAutoHasher[any]()
+	equal := genmap.NewAutoEqual[any]()
+	if !equal(nil, nil) || equal(nil, 0) || equal(int32(1), int64(1)) {
+		t.Errorf("unexpected interface comparison")
+	}
+	if !equal([]int{1, 2}, []int{1, 2}) || hash([]int{1, 2}) != hash([]int{1, 2}) {
+		t.Errorf("expected equal slices with the same hash")
+	}
+	f := func() {}
+	if equal(f, f) {
+		t.Errorf("expected non nil functions to be different")
+	}
+}
+
+func TestAutoHasherMap(t *testing.T) {
+	m := genmap.NewMap[MyKey, int](genmap.NewAutoEqual[MyKey](), genmap.NewAutoHasher[MyKey](), 16)
+	m.Put(MyKey{1, []string{"a", "b"}}, 1)
+	m.Put(MyKey{1, []string{"a", "b"}}, 2)
+	m.Put(MyKey{1, []string{"a"}}, 3)
+	if m.Len() != 2 {
+		t.Errorf("expected length 2, got %d", m.Len())
+	}
+	if v, ok := m.Get(MyKey{1, []string{"a", "b"}}); !ok || v != 2 {
+		t.Errorf("expected value 2, got %v", v)
+	}
+}
+
+func BenchmarkAutoHasher(b *testing.B) {
+	hash := genmap.NewAutoHasher[MyKey]()
+	k := MyKey{1, []string{"a", "b"}}
+	for i := 0; i < b.N; i++ {
+		hash(k)
+	}
+}
+
+func BenchmarkMyKeyHasher(b *testing.B) {
+	hash := NewMyKeyHasher()
+	k := MyKey{1, []string{"a", "b"}}
+	for i := 0; i < b.N; i++ {
+		hash(k)
+	}
+}
@@ -0,0 +1,122 @@
+package genmap
+
+import "iter"
+
+// BiMap is a one-to-one map: each value is associated with a single key, so
+// that keys can be looked up by value. Both keys and values can be of any
+// type, with their own equality and hash functions.
+// It is built on two Maps, one from keys to values and one from values to keys.
+// BiMap instance should be instantiated using the NewBiMap function.
+type BiMap[K any, V any] struct {
+	forward  *Map[K, V]
+	backward *Map[V, K]
+	inverse  *BiMap[V, K]
+}
+
+// NewBiMap returns a new instance of BiMap[K, V] with the given equality and
+// hash functions for the keys and for the values.
+// The options configure both underlying Maps.
+func NewBiMap[K any, V any](keyEqual func(k1, k2 K) bool, keyHash func(k K) uint64, valueEqual func(v1, v2 V) bool, valueHash func(v V) uint64, opts ...MapOption) *BiMap[K, V] {
+	bm := &BiMap[K, V]{
+		forward:  NewMapWithOptions[K, V](keyEqual, keyHash, opts...),
+		backward: NewMapWithOptions[V, K](valueEqual, valueHash, opts...),
+	}
+	bm.inverse = &BiMap[V, K]{forward: bm.backward, backward: bm.forward, inverse: bm}
+	return bm
+}
+
+// Len returns the number of key-value pairs in the map.
+func (bm *BiMap[K, V]) Len() int {
+	if bm == nil {
+		return 0
+	}
+	return bm.forward.Len()
+}
+
+// Clear removes all key-value pairs from the map.
+func (bm *BiMap[K, V]) Clear() {
+	bm.forward.Clear()
+	bm.backward.Clear()
+}
+
+// GetByKey returns the value associated with the given key.
+func (bm *BiMap[K, V]) GetByKey(key K) (V, bool) {
+	if bm == nil {
+		return *new(V), false
+	}
+	return bm.forward.Get(key)
+}
+
+// GetByValue returns the key associated with the given value.
+func (bm *BiMap[K, V]) GetByValue(val V) (K, bool) {
+	if bm == nil {
+		return *new(K), false
+	}
+	return bm.backward.Get(val)
+}
+
+// Put associates the given key and value, replacing the previous value of the
+// key if any.
+// It returns false, and leaves the map unchanged, if the value is already
+// associated with another key. Use ForcePut to replace that association.
+func (bm *BiMap[K, V]) Put(key K, val V) bool {
+	if k, ok := bm.backward.Get(val); ok {
+		if !bm.forward.equal(k, key) {
+			return false
+		}
+	}
+	bm.put(key, val)
+	return true
+}
+
+// ForcePut associates the given key and value, removing the previous value of
+// the key and the previous key of the value if any.
+func (bm *BiMap[K, V]) ForcePut(key K, val V) {
+	bm.RemoveByValue(val)
+	bm.put(key, val)
+}
+
+// put associates key and val, val not being associated with another key.
+func (bm *BiMap[K, V]) put(key K, val V) {
+	if old, ok := bm.forward.Get(key); ok {
+		bm.backward.Remove(old)
+	}
+	bm.forward.Put(key, val)
+	bm.backward.Put(val, key)
+}
+
+// RemoveByKey removes the given key and returns its value.
+func (bm *BiMap[K, V]) RemoveByKey(key K) (V, bool) {
+	removed, ok := bm.forward.Remove(key)
+	if !ok {
+		return *new(V), false
+	}
+	bm.backward.Remove(removed.Value)
+	return removed.Value, true
+}
+
+// RemoveByValue removes the given value and returns its key.
+func (bm *BiMap[K, V]) RemoveByValue(val V) (K, bool) {
+	return bm.inverse.RemoveByKey(val)
+}
+
+// Inverse returns the inverse view of the map, from values to keys. The view
+// shares the pairs of bm: modifying one modifies the other.
+func (bm *BiMap[K, V]) Inverse() *BiMap[V, K] {
+	return bm.inverse
+}
+
+// All returns an iterator over the key-value pairs of the map.
+// The map must not be modified during the iteration.
+func (bm *BiMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if bm == nil {
+			return
+		}
+		for k, v := range bm.forward.All() {
+			if !yield(k, v) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,96 @@
+package genmap_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func newBiMap() *genmap.BiMap[[]string, []int] {
+	return genmap.NewBiMap[[]string, []int](
+		slices.Equal[[]string], genmap.NewAutoHasher[[]string](),
+		slices.Equal[[]int], genmap.NewAutoHasher[[]int](),
+	)
+}
+
+func TestBiMap(t *testing.T) {
+	bm := newBiMap()
+	if !bm.Put([]string{"a"}, []int{1}) || !bm.Put([]string{"b"}, []int{2}) {
+		t.Fatal("expected Put to succeed")
+	}
+	if v, ok := bm.GetByKey([]string{"a"}); !ok || !slices.Equal(v, []int{1}) {
+		t.Errorf("GetByKey = %v, %v", v, ok)
+	}
+	if k, ok := bm.GetByValue([]int{2}); !ok || !slices.Equal(k, []string{"b"}) {
+		t.Errorf("GetByValue = %v, %v", k, ok)
+	}
+
+	// the value is already associated with another key
+	if bm.Put([]string{"c"}, []int{1}) {
+		t.Error("expected Put to fail")
+	}
+	if _, ok := bm.GetByKey([]string{"c"}); ok || bm.Len() != 2 {
+		t.Error("failed Put must not modify the map")
+	}
+	// putting the same pair again is allowed
+	if !bm.Put([]string{"a"}, []int{1}) || bm.Len() != 2 {
+		t.Error("expected Put of an existing pair to succeed")
+	}
+
+	// replacing the value of a key frees the previous value
+	if !bm.Put([]string{"a"}, []int{3}) {
+		t.Fatal("expected Put to succeed")
+	}
+	if _, ok := bm.GetByValue([]int{1}); ok {
+		t.Error("expected previous value to be removed")
+	}
+
+	bm.ForcePut([]string{"c"}, []int{3})
+	if _, ok := bm.GetByKey([]string{"a"}); ok {
+		t.Error("expected ForcePut to remove the previous key of the value")
+	}
+	if k, ok := bm.GetByValue([]int{3}); !ok || !slices.Equal(k, []string{"c"}) || bm.Len() != 2 {
+		t.Errorf("GetByValue = %v, %v", k, ok)
+	}
+
+	if v, ok := bm.RemoveByKey([]string{"c"}); !ok || !slices.Equal(v, []int{3}) {
+		t.Errorf("RemoveByKey = %v, %v", v, ok)
+	}
+	if k, ok := bm.RemoveByValue([]int{2}); !ok || !slices.Equal(k, []string{"b"}) {
+		t.Errorf("RemoveByValue = %v, %v", k, ok)
+	}
+	if bm.Len() != 0 || bm.Inverse().Len() != 0 {
+		t.Error("expected empty map")
+	}
+	if _, ok := bm.RemoveByKey([]string{"c"}); ok {
+		t.Error("expected RemoveByKey to fail")
+	}
+}
+
+func TestBiMapInverse(t *testing.T) {
+	bm := newBiMap()
+	inv := bm.Inverse()
+	if inv.Inverse() != bm {
+		t.Error("expected the inverse of the inverse to be the map")
+	}
+	inv.Put([]int{1}, []string{"a"})
+	if v, ok := bm.GetByKey([]string{"a"}); !ok || !slices.Equal(v, []int{1}) {
+		t.Errorf("GetByKey = %v, %v", v, ok)
+	}
+	bm.Put([]string{"b"}, []int{2})
+	n := 0
+	for v, k := range inv.All() {
+		if got, _ := bm.GetByValue(v); !slices.Equal(got, k) {
+			t.Errorf("unexpected pair %v, %v", v, k)
+		}
+		n++
+	}
+	if n != 2 {
+		t.Errorf("expected 2 pairs, got %d", n)
+	}
+	inv.Clear()
+	if bm.Len() != 0 {
+		t.Error("expected empty map")
+	}
+}
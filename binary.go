@@ -0,0 +1,321 @@
+package genmap
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"slices"
+)
+
+// Binary format written by Map.WriteBinary:
+//
+//	header: "GMAP", version byte, flags byte, uvarint number of elements
+//	blocks: uvarint number of elements in the block (0 ends the stream),
+//	        uvarint payload size, payload, CRC-32C of the payload (little endian)
+//	payload: for each element, the hash (8 bytes, little endian) if
+//	        binaryHashes is set, then the uvarint size and the encoding of the
+//	        key, then the uvarint size and the encoding of the value
+//
+// The elements are written in blocks so that a stream can be read with
+// bounded memory, and corruption is detected before the elements of a block
+// are added to the map.
+
+const (
+	binaryMagic   = "GMAP"
+	binaryVersion = 1
+	binaryHashes  = 1 << 0 // flag: the hashes of the keys are stored
+
+	binaryBlockSize    = 64 << 10 // payload size above which a block is written
+	binaryMaxBlockSize = 1 << 30  // larger blocks are considered corrupted
+)
+
+var crc32c = crc32.MakeTable(crc32.Castagnoli)
+
+// ErrInvalidFormat is returned by Map.ReadBinary when the stream is not a
+// valid map encoding, in particular when a checksum does not match.
+var ErrInvalidFormat = errors.New("genmap: invalid binary map format")
+
+// EncodeOption configures the binary encoding of Map.WriteBinary.
+type EncodeOption func(*encodeOptions)
+
+type encodeOptions struct {
+	hashes bool
+}
+
+// WithStoredHashes stores the hashes of the keys, at the cost of 8 bytes per
+// element, so that ReadBinary with the TrustStoredHashes option does not need
+// to hash them again. Without that option, the stored hashes are ignored.
+func WithStoredHashes() EncodeOption {
+	return func(o *encodeOptions) {
+		o.hashes = true
+	}
+}
+
+// DecodeOption configures the decoding of Map.ReadBinary.
+type DecodeOption func(*decodeOptions)
+
+type decodeOptions struct {
+	trustHashes bool
+}
+
+// TrustStoredHashes makes ReadBinary use the hashes stored by WithStoredHashes
+// instead of hashing the keys again.
+//
+// Only the hash of the first key of each block is checked against the hash
+// function of the map (the hash of every key with the genmapdebug build tag);
+// if it differs, the keys are hashed again from then on. A stored hash which
+// does not match the hash function is otherwise not detected: the key is
+// stored under a wrong hash, Get, Remove and Entry then miss it, and the same
+// key can be added a second time. This happens when the hash function of the
+// map differs from the writer's on some keys only, or is randomly seeded like
+// those returned by NewHasher, and when the stream was written by a faulty or
+// malicious writer (the checksums only detect corruption after writing).
+// Only use this option for streams written by a trusted program, with the
+// same deterministic hash function.
+func TrustStoredHashes() DecodeOption {
+	return func(o *decodeOptions) {
+		o.trustHashes = true
+	}
+}
+
+// WriteBinary writes the elements of the map to w in a compact binary format,
+// encoding the keys and values with the given codecs, and returns the number
+// of bytes written. The map can be decoded with ReadBinary.
+// (The methods are not named WriteTo and ReadFrom since their signatures
+// differ from those of io.WriterTo and io.ReaderFrom.)
+func (m *Map[K, V]) WriteBinary(w io.Writer, keyCodec Codec[K], valueCodec Codec[V], opts ...EncodeOption) (int64, error) {
+	var o encodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	bw := binaryWriter{w: w}
+	header := append([]byte(binaryMagic), binaryVersion, 0)
+	if o.hashes {
+		header[len(header)-1] |= binaryHashes
+	}
+	bw.write(binary.AppendUvarint(header, uint64(m.Len())))
+
+	var payload, scratch []byte
+	n := 0
+	var err error
+	for elem := range m.elements(false) {
+		if o.hashes {
+			payload = binary.LittleEndian.AppendUint64(payload, elem.hash)
+		}
+		if scratch, err = keyCodec.Append(scratch[:0], elem.Key); err != nil {
+			return bw.n, err
+		}
+		payload = binary.AppendUvarint(payload, uint64(len(scratch)))
+		payload = append(payload, scratch...)
+		if scratch, err = valueCodec.Append(scratch[:0], elem.Value); err != nil {
+			return bw.n, err
+		}
+		payload = binary.AppendUvarint(payload, uint64(len(scratch)))
+		payload = append(payload, scratch...)
+		n++
+		if len(payload) >= binaryBlockSize {
+			bw.writeBlock(n, payload)
+			payload, n = payload[:0], 0
+		}
+		if bw.err != nil {
+			return bw.n, bw.err
+		}
+	}
+	if n > 0 {
+		bw.writeBlock(n, payload)
+	}
+	bw.write([]byte{0})
+	return bw.n, bw.err
+}
+
+// binaryWriter counts the bytes written and keeps the first error.
+type binaryWriter struct {
+	w   io.Writer
+	n   int64
+	err error
+}
+
+func (bw *binaryWriter) write(b []byte) {
+	if bw.err != nil {
+		return
+	}
+	n, err := bw.w.Write(b)
+	bw.n += int64(n)
+	bw.err = err
+}
+
+func (bw *binaryWriter) writeBlock(n int, payload []byte) {
+	header := binary.AppendUvarint(nil, uint64(n))
+	header = binary.AppendUvarint(header, uint64(len(payload)))
+	bw.write(header)
+	bw.write(payload)
+	bw.write(binary.LittleEndian.AppendUint32(nil, crc32.Checksum(payload, crc32c)))
+}
+
+// ReadBinary reads a map written by WriteBinary from r, decoding the keys and
+// values with the given codecs, and returns the number of bytes read. The
+// decoded elements are added to the map, replacing the values of existing keys.
+// ReadBinary does not read past the end of the encoded map.
+// Since the equality and hash functions cannot be decoded, the map must have
+// been created with NewMap or NewMapWithOptions beforehand.
+// If an error is returned, only some of the elements may have been added.
+func (m *Map[K, V]) ReadBinary(r io.Reader, keyCodec Codec[K], valueCodec Codec[V], opts ...DecodeOption) (int64, error) {
+	if m == nil || m.hash == nil {
+		return 0, errors.New("genmap: ReadBinary on a map not created with NewMap")
+	}
+	var o decodeOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	br := binaryReader{r: r}
+	header := make([]byte, len(binaryMagic)+2)
+	if err := br.readFull(header); err != nil {
+		return br.n, err
+	}
+	if string(header[:len(binaryMagic)]) != binaryMagic {
+		return br.n, fmt.Errorf("%w: bad magic", ErrInvalidFormat)
+	}
+	if version := header[len(binaryMagic)]; version != binaryVersion {
+		return br.n, fmt.Errorf("genmap: unsupported binary map format version %d", version)
+	}
+	flags := header[len(binaryMagic)+1]
+	if flags&^binaryHashes != 0 {
+		return br.n, fmt.Errorf("%w: unknown flags %#x", ErrInvalidFormat, flags)
+	}
+	stored := flags&binaryHashes != 0
+	trusted := stored && o.trustHashes
+	total, err := br.readUvarint()
+	if err != nil {
+		return br.n, err
+	}
+
+	var payload []byte
+	var read uint64
+	for {
+		n, err := br.readUvarint()
+		if err != nil {
+			return br.n, err
+		}
+		if n == 0 {
+			break
+		}
+		size, err := br.readUvarint()
+		if err != nil {
+			return br.n, err
+		}
+		if size > binaryMaxBlockSize {
+			return br.n, fmt.Errorf("%w: block too large", ErrInvalidFormat)
+		}
+		if payload, err = br.readBlock(payload[:0], size+4); err != nil {
+			return br.n, err
+		}
+		sum := binary.LittleEndian.Uint32(payload[size:])
+		payload = payload[:size]
+		if crc32.Checksum(payload, crc32c) != sum {
+			return br.n, fmt.Errorf("%w: checksum mismatch", ErrInvalidFormat)
+		}
+		if trusted, err = m.decodeBlock(payload, n, stored, trusted, keyCodec, valueCodec); err != nil {
+			return br.n, err
+		}
+		read += n
+	}
+	if read != total {
+		return br.n, fmt.Errorf("%w: %d elements read, want %d", ErrInvalidFormat, read, total)
+	}
+	return br.n, nil
+}
+
+// decodeBlock adds the n elements of payload to the map. If stored is set, the
+// payload holds the hashes of the keys, which are used if trusted is set
+// (TrustStoredHashes) and the hash function of the map gives the same hash for
+// the first key (for every key with the genmapdebug build tag).
+// decodeBlock returns whether the stored hashes can be used for the next blocks.
+func (m *Map[K, V]) decodeBlock(payload []byte, n uint64, stored, trusted bool, keyCodec Codec[K], valueCodec Codec[V]) (bool, error) {
+	errTruncated := fmt.Errorf("%w: truncated block", ErrInvalidFormat)
+	for i := uint64(0); i < n; i++ {
+		var hash uint64
+		if stored {
+			if len(payload) < 8 {
+				return trusted, errTruncated
+			}
+			hash = binary.LittleEndian.Uint64(payload)
+			payload = payload[8:]
+		}
+		var fields [2][]byte
+		for f := range fields {
+			size, k := binary.Uvarint(payload)
+			if k <= 0 || uint64(len(payload)-k) < size {
+				return trusted, errTruncated
+			}
+			fields[f] = payload[k : k+int(size)]
+			payload = payload[k+int(size):]
+		}
+		key, err := keyCodec.Decode(fields[0])
+		if err != nil {
+			return trusted, err
+		}
+		val, err := valueCodec.Decode(fields[1])
+		if err != nil {
+			return trusted, err
+		}
+		if trusted && (i == 0 || debug) && m.hash(key) != hash {
+			trusted = false
+		}
+		if !trusted {
+			hash = m.hash(key)
+		}
+		makeOptionalEntry(m, hash, key).Insert(val)
+	}
+	if len(payload) != 0 {
+		return trusted, fmt.Errorf("%w: trailing data in block", ErrInvalidFormat)
+	}
+	return trusted, nil
+}
+
+// binaryReader counts the bytes read, and reads the varints byte by byte so
+// as not to read past the end of the encoded map.
+type binaryReader struct {
+	r io.Reader
+	n int64
+}
+
+func (br *binaryReader) readFull(b []byte) error {
+	n, err := io.ReadFull(br.r, b)
+	br.n += int64(n)
+	if err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	return err
+}
+
+// readBlock appends size bytes read from the stream to b. The bytes are read
+// in chunks, so that a corrupted block size does not allocate much more memory
+// than the remaining data.
+func (br *binaryReader) readBlock(b []byte, size uint64) ([]byte, error) {
+	for size > 0 {
+		chunk := int(min(size, binaryBlockSize))
+		b = slices.Grow(b, chunk)
+		if err := br.readFull(b[len(b) : len(b)+chunk]); err != nil {
+			return b, err
+		}
+		b = b[:len(b)+chunk]
+		size -= uint64(chunk)
+	}
+	return b, nil
+}
+
+func (br *binaryReader) ReadByte() (byte, error) {
+	var b [1]byte
+	err := br.readFull(b[:])
+	return b[0], err
+}
+
+func (br *binaryReader) readUvarint() (uint64, error) {
+	x, err := binary.ReadUvarint(br)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		err = fmt.Errorf("%w: %v", ErrInvalidFormat, err)
+	}
+	return x, err
+}
@@ -0,0 +1,205 @@
+package genmap_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"maps"
+	"net/netip"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+// intSliceCodec encodes []int keys as a sequence of varints.
+var intSliceCodec = genmap.NewCodec(
+	func(b []byte, v []int) ([]byte, error) {
+		for _, x := range v {
+			b = binary.AppendVarint(b, int64(x))
+		}
+		return b, nil
+	},
+	func(data []byte) ([]int, error) {
+		var v []int
+		for len(data) > 0 {
+			x, n := binary.Varint(data)
+			if n <= 0 {
+				return nil, errors.New("invalid varint")
+			}
+			v = append(v, int(x))
+			data = data[n:]
+		}
+		return v, nil
+	},
+)
+
+func TestMapBinary(t *testing.T) {
+	forEachBackend(t, testMapBinary)
+}
+
+func testMapBinary(t *testing.T, opts ...genmap.MapOption) {
+	hashCalls := 0
+	hash := func(k int) uint64 {
+		hashCalls++
+		return uint64(k) * 0x9e3779b97f4a7c15
+	}
+	m := genmap.NewMapWithOptions[int, string](genmap.Equal[int], hash, opts...)
+	// more than one block
+	for i := range 20000 {
+		m.Put(i-10000, strconv.Itoa(i))
+	}
+	for _, test := range []struct {
+		encOpts []genmap.EncodeOption
+		decOpts []genmap.DecodeOption
+	}{
+		{nil, nil},
+		{[]genmap.EncodeOption{genmap.WithStoredHashes()}, nil},
+		{[]genmap.EncodeOption{genmap.WithStoredHashes()}, []genmap.DecodeOption{genmap.TrustStoredHashes()}},
+	} {
+		encOpts := test.encOpts
+		var buf bytes.Buffer
+		n, err := m.WriteBinary(&buf, genmap.IntCodec[int](), genmap.StringCodec[string](), encOpts...)
+		if err != nil || n != int64(buf.Len()) {
+			t.Fatalf("WriteBinary returned %d, %v for %d bytes", n, err, buf.Len())
+		}
+		// followed by other data, which must not be read
+		buf.WriteString("next")
+
+		got := genmap.NewMapWithOptions[int, string](genmap.Equal[int], hash, opts...)
+		got.Put(-1, "old")
+		got.Put(20000, "kept")
+		hashCalls = 0
+		read, err := got.ReadBinary(&buf, genmap.IntCodec[int](), genmap.StringCodec[string](), test.decOpts...)
+		if err != nil || read != n {
+			t.Fatalf("ReadBinary returned %d, %v, want %d", read, err, n)
+		}
+		// with the genmapdebug build tag, every stored hash is checked
+		if trusted := len(test.decOpts) > 0; trusted && !debugBuild && hashCalls > 100 || !trusted && hashCalls < 20000 {
+			t.Errorf("expected the stored hashes to be used (%v), got %d hash calls", trusted, hashCalls)
+		}
+		if buf.String() != "next" {
+			t.Errorf("ReadBinary read past the end of the map")
+		}
+		want := maps.Collect(m.All())
+		want[20000] = "kept"
+		if !maps.Equal(maps.Collect(got.All()), want) {
+			t.Error("decoded map differs")
+		}
+	}
+}
+
+func TestMapBinaryHashMismatch(t *testing.T) {
+	// the hash function of the decoded map differs: the stored hashes must
+	// not be used
+	m := genmap.NewMap[[]int, netip.Addr](slices.Equal[[]int], genmap.NewAutoHasher[[]int](), 16)
+	for i := range 100 {
+		m.Put([]int{i, i * 2}, netip.AddrFrom4([4]byte{10, 0, 0, byte(i)}))
+	}
+	var buf bytes.Buffer
+	if _, err := m.WriteBinary(&buf, intSliceCodec, genmap.BinaryCodec[netip.Addr](), genmap.WithStoredHashes()); err != nil {
+		t.Fatal(err)
+	}
+	seeded := genmap.NewAutoHasher[[]int]()
+	got := genmap.NewMap[[]int, netip.Addr](slices.Equal[[]int], func(k []int) uint64 {
+		return genmap.CombineHash(1, seeded(k))
+	}, 16)
+	if _, err := got.ReadBinary(&buf, intSliceCodec, genmap.BinaryCodec[netip.Addr](), genmap.TrustStoredHashes()); err != nil {
+		t.Fatal(err)
+	}
+	if got.Len() != 100 {
+		t.Errorf("expected 100 elements, got %d", got.Len())
+	}
+	for i := range 100 {
+		if v, ok := got.Get([]int{i, i * 2}); !ok || v != netip.AddrFrom4([4]byte{10, 0, 0, byte(i)}) {
+			t.Errorf("unexpected value %v, %v for %d", v, ok, i)
+		}
+	}
+}
+
+func TestMapBinaryHashPartialMismatch(t *testing.T) {
+	m := genmap.NewMap[int, int](genmap.Equal[int], func(k int) uint64 { return uint64(k % 3) }, 16)
+	for i := range 30 {
+		m.Put(i*3, i)
+	}
+	m.Put(1, 1)
+	var buf bytes.Buffer
+	if _, err := m.WriteBinary(&buf, genmap.IntCodec[int](), genmap.IntCodec[int](), genmap.WithStoredHashes()); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// same hash as the writer for the multiples of 15 only
+	missing := func(opts ...genmap.DecodeOption) int {
+		got := genmap.NewMap[int, int](genmap.Equal[int], func(k int) uint64 { return uint64(k % 5) }, 16)
+		if _, err := got.ReadBinary(bytes.NewReader(data), genmap.IntCodec[int](), genmap.IntCodec[int](), opts...); err != nil {
+			t.Fatal(err)
+		}
+		missing := 0
+		for k, v := range m.All() {
+			if got, ok := got.Get(k); !ok || got != v {
+				missing++
+			}
+		}
+		return missing
+	}
+	if n := missing(); n != 0 {
+		t.Errorf("expected all the keys to be found, %d missing", n)
+	}
+	// the first key is 0 with both hash functions: with trusted hashes, the
+	// mismatch is only detected when every hash is checked
+	if n := missing(genmap.TrustStoredHashes()); debugBuild && n != 0 || !debugBuild && n == 0 {
+		t.Errorf("unexpected number of missing keys with trusted hashes: %d", n)
+	}
+}
+
+func TestMapBinaryCorrupted(t *testing.T) {
+	m := genmap.NewMap[string, uint16](genmap.Equal[string], genmap.NewHasher[string](), 16)
+	for i := range 100 {
+		m.Put(strconv.Itoa(i), uint16(i))
+	}
+	var buf bytes.Buffer
+	if _, err := m.WriteBinary(&buf, genmap.StringCodec[string](), genmap.UintCodec[uint16]()); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	read := func(data []byte) (*genmap.Map[string, uint16], error) {
+		got := genmap.NewMap[string, uint16](genmap.Equal[string], genmap.NewHasher[string](), 16)
+		_, err := got.ReadBinary(bytes.NewReader(data), genmap.StringCodec[string](), genmap.UintCodec[uint16]())
+		return got, err
+	}
+
+	for pos := range data {
+		corrupted := bytes.Clone(data)
+		corrupted[pos] ^= 0x10
+		if got, err := read(corrupted); err == nil {
+			t.Errorf("expected an error for a corrupted byte at %d, got %d elements", pos, got.Len())
+		}
+	}
+	// the elements of a corrupted block are not added
+	corrupted := bytes.Clone(data)
+	corrupted[len(corrupted)/2] ^= 0x10
+	if got, err := read(corrupted); !errors.Is(err, genmap.ErrInvalidFormat) || got.Len() != 0 {
+		t.Errorf("expected a checksum error and no element, got %d elements, %v", got.Len(), err)
+	}
+	if _, err := read(data[:len(data)-1]); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected unexpected EOF for a truncated stream, got %v", err)
+	}
+	// a block size larger than the stream is read in chunks, not allocated
+	huge := binary.AppendUvarint([]byte("GMAP\x01\x00\x01\x01"), 1<<30-1)
+	if _, err := read(append(huge, "short"...)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("expected unexpected EOF for a truncated block, got %v", err)
+	}
+
+	// values overflowing the value type
+	wide := genmap.NewMap[string, uint32](genmap.Equal[string], genmap.NewHasher[string](), 16)
+	wide.Put("big", 1<<20)
+	buf.Reset()
+	if _, err := wide.WriteBinary(&buf, genmap.StringCodec[string](), genmap.UintCodec[uint32]()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := read(buf.Bytes()); err == nil {
+		t.Error("expected an overflow error")
+	}
+}
@@ -0,0 +1,361 @@
+// Package cache provides a bounded cache built on genmap.Map, so that it
+// accepts any key type given a hash and an equality function.
+//
+// The cache is bounded by a maximum number of entries and/or a maximum total
+// cost, and evicts entries according to a LRU or LFU policy.
+//
+// Example:
+//
+//	c := cache.New[MyKey, MyValue](MyKeyEquals, NewMyKeyHasher(), cache.Config[MyKey, MyValue]{
+//	    MaxEntries: 1000,
+//	    Policy:     cache.LFU,
+//	    OnEvict: func(k MyKey, v MyValue) {
+//	        log.Printf("evicted %v", k)
+//	    },
+//	})
+//	c.Put(k, v)
+//	v, ok := c.Get(k)
+package cache
+
+import (
+	"iter"
+
+	"github.com/ronanh/genmap"
+)
+
+// Policy is the eviction policy of a Cache.
+type Policy int
+
+const (
+	// LRU evicts the least recently used entry.
+	LRU Policy = iota
+	// LFU evicts the least frequently used entry, the least recently used one
+	// among entries with the same frequency.
+	LFU
+)
+
+// Config configures a Cache. At least one of MaxEntries and MaxCost should be set,
+// otherwise the cache is unbounded.
+type Config[K any, V any] struct {
+	// MaxEntries is the maximum number of entries, 0 for no limit.
+	MaxEntries int
+	// MaxCost is the maximum total cost of the entries, 0 for no limit.
+	MaxCost int64
+	// Cost returns the cost of an entry. If nil, every entry costs 1.
+	Cost func(key K, value V) int64
+	// Policy is the eviction policy. The default is LRU.
+	Policy Policy
+	// OnEvict, if set, is called for each entry evicted to satisfy the bounds.
+	// It is not called for entries removed with Remove or Clear.
+	OnEvict func(key K, value V)
+}
+
+// Stats holds the statistics of a Cache.
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// HitRatio returns the ratio of hits over lookups, 0 if there was no lookup.
+func (s Stats) HitRatio() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+type entry[K any, V any] struct {
+	key        K
+	value      V
+	cost       int64
+	freq       *freqNode[K, V] // LFU only
+	prev, next *entry[K, V]
+}
+
+// list is a circular doubly linked list of entries, ordered from the least
+// to the most recently used.
+type list[K any, V any] struct {
+	root entry[K, V]
+}
+
+func (l *list[K, V]) init() {
+	l.root.next = &l.root
+	l.root.prev = &l.root
+}
+
+func (l *list[K, V]) empty() bool {
+	return l.root.next == &l.root
+}
+
+func (l *list[K, V]) front() *entry[K, V] {
+	return l.root.next
+}
+
+func (l *list[K, V]) pushBack(e *entry[K, V]) {
+	e.prev = l.root.prev
+	e.next = &l.root
+	l.root.prev.next = e
+	l.root.prev = e
+}
+
+func (l *list[K, V]) remove(e *entry[K, V]) {
+	e.prev.next = e.next
+	e.next.prev = e.prev
+	e.prev = nil
+	e.next = nil
+}
+
+// freqNode groups the entries used count times (LFU only).
+// The nodes form a circular list sorted by count.
+type freqNode[K any, V any] struct {
+	count      uint64
+	entries    list[K, V]
+	prev, next *freqNode[K, V]
+}
+
+// Cache is a bounded map evicting entries according to its policy.
+// Cache is not safe for concurrent use.
+// Cache instance should be instantiated using the New function.
+type Cache[K any, V any] struct {
+	cfg   Config[K, V]
+	m     *genmap.Map[K, *entry[K, V]]
+	lru   list[K, V]     // LRU only
+	freqs freqNode[K, V] // LFU only, sentinel of the frequency list
+	cost  int64
+	stats Stats
+}
+
+// New returns a new Cache[K, V] with the given equality and hash functions
+// and configuration. The options configure the underlying genmap.Map.
+func New[K any, V any](equal func(k1, k2 K) bool, hash func(k K) uint64, cfg Config[K, V], opts ...genmap.MapOption) *Cache[K, V] {
+	if cfg.MaxEntries < 0 || cfg.MaxCost < 0 {
+		panic("negative cache bound")
+	}
+	if cfg.MaxEntries > 0 {
+		opts = append([]genmap.MapOption{genmap.WithCapacity(cfg.MaxEntries)}, opts...)
+	}
+	c := &Cache[K, V]{
+		cfg: cfg,
+		m:   genmap.NewMapWithOptions[K, *entry[K, V]](equal, hash, opts...),
+	}
+	c.lru.init()
+	c.freqs.next = &c.freqs
+	c.freqs.prev = &c.freqs
+	return c
+}
+
+// Len returns the number of entries in the cache.
+func (c *Cache[K, V]) Len() int {
+	return c.m.Len()
+}
+
+// Cost returns the total cost of the entries in the cache.
+func (c *Cache[K, V]) Cost() int64 {
+	return c.cost
+}
+
+// Stats returns the hit, miss and eviction counts of the cache.
+func (c *Cache[K, V]) Stats() Stats {
+	return c.stats
+}
+
+// Get returns the value associated with the given key and records the access.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	e, ok := c.m.Get(key)
+	if !ok {
+		c.stats.Misses++
+		return *new(V), false
+	}
+	c.stats.Hits++
+	c.touch(e)
+	return e.value, true
+}
+
+// Peek returns the value associated with the given key without recording the
+// access nor updating the statistics.
+func (c *Cache[K, V]) Peek(key K) (V, bool) {
+	e, ok := c.m.Get(key)
+	if !ok {
+		return *new(V), false
+	}
+	return e.value, true
+}
+
+// Put inserts or replaces the value of the given key, then evicts other entries
+// until the cache is within its bounds. An entry whose cost alone exceeds
+// MaxCost is evicted right away, leaving the other entries untouched.
+func (c *Cache[K, V]) Put(key K, val V) {
+	cost := int64(1)
+	if c.cfg.Cost != nil {
+		cost = c.cfg.Cost(key, val)
+	}
+	var put *entry[K, V]
+	c.m.Upsert(key, func(elem *genmap.MapElement[K, *entry[K, V]], exists bool) {
+		if exists {
+			put = elem.Value
+			put.value = val
+			c.cost += cost - put.cost
+			put.cost = cost
+			c.touch(put)
+			return
+		}
+		put = &entry[K, V]{key: key, value: val, cost: cost}
+		elem.Value = put
+		c.cost += cost
+		c.add(put)
+	})
+	if c.cfg.MaxCost > 0 && cost > c.cfg.MaxCost {
+		// evicting the other entries would not make room for it
+		c.evictEntry(put)
+		return
+	}
+	c.evict(put)
+}
+
+// Remove removes the given key from the cache.
+// It returns false if the key was not in the cache.
+func (c *Cache[K, V]) Remove(key K) bool {
+	removed, ok := c.m.Remove(key)
+	if ok {
+		c.unlink(removed.Value)
+	}
+	return ok
+}
+
+// Clear removes all entries from the cache. The statistics are kept.
+func (c *Cache[K, V]) Clear() {
+	c.m.Clear()
+	c.lru.init()
+	c.freqs.next = &c.freqs
+	c.freqs.prev = &c.freqs
+	c.cost = 0
+}
+
+// All returns an iterator over the entries of the cache, from the next to be
+// evicted to the last. The cache must not be modified during the iteration.
+func (c *Cache[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for e := range c.entries() {
+			if !yield(e.key, e.value) {
+				return
+			}
+		}
+	}
+}
+
+// entries returns an iterator over the entries in eviction order.
+func (c *Cache[K, V]) entries() iter.Seq[*entry[K, V]] {
+	return func(yield func(*entry[K, V]) bool) {
+		if c.cfg.Policy == LFU {
+			for f := c.freqs.next; f != &c.freqs; f = f.next {
+				for e := f.entries.front(); e != &f.entries.root; e = e.next {
+					if !yield(e) {
+						return
+					}
+				}
+			}
+			return
+		}
+		for e := c.lru.front(); e != &c.lru.root; e = e.next {
+			if !yield(e) {
+				return
+			}
+		}
+	}
+}
+
+// overflows reports whether the cache exceeds its bounds.
+func (c *Cache[K, V]) overflows() bool {
+	return (c.cfg.MaxEntries > 0 && c.m.Len() > c.cfg.MaxEntries) ||
+		(c.cfg.MaxCost > 0 && c.cost > c.cfg.MaxCost)
+}
+
+// evict removes entries according to the policy until the cache is within its
+// bounds. The entry just put is evicted last: with LFU, a new entry would
+// otherwise always be the least frequently used one.
+func (c *Cache[K, V]) evict(put *entry[K, V]) {
+	for c.overflows() {
+		e := put
+		for victim := range c.entries() {
+			if victim != put {
+				e = victim
+				break
+			}
+		}
+		c.evictEntry(e)
+	}
+}
+
+// evictEntry removes the given entry and notifies OnEvict.
+func (c *Cache[K, V]) evictEntry(e *entry[K, V]) {
+	c.m.Remove(e.key)
+	c.unlink(e)
+	c.stats.Evictions++
+	if c.cfg.OnEvict != nil {
+		c.cfg.OnEvict(e.key, e.value)
+	}
+}
+
+// add links a new entry as the most recently used one, with a frequency of 1.
+func (c *Cache[K, V]) add(e *entry[K, V]) {
+	if c.cfg.Policy != LFU {
+		c.lru.pushBack(e)
+		return
+	}
+	f := c.freqs.next
+	if f == &c.freqs || f.count != 1 {
+		f = c.newFreqNode(1, &c.freqs)
+	}
+	e.freq = f
+	f.entries.pushBack(e)
+}
+
+// touch records an access to the entry.
+func (c *Cache[K, V]) touch(e *entry[K, V]) {
+	if c.cfg.Policy != LFU {
+		c.lru.remove(e)
+		c.lru.pushBack(e)
+		return
+	}
+	f := e.freq
+	next := f.next
+	if next == &c.freqs || next.count != f.count+1 {
+		next = c.newFreqNode(f.count+1, f)
+	}
+	f.entries.remove(e)
+	if f.entries.empty() {
+		c.removeFreqNode(f)
+	}
+	e.freq = next
+	next.entries.pushBack(e)
+}
+
+// unlink removes the entry from the policy lists.
+func (c *Cache[K, V]) unlink(e *entry[K, V]) {
+	c.cost -= e.cost
+	if c.cfg.Policy != LFU {
+		c.lru.remove(e)
+		return
+	}
+	f := e.freq
+	f.entries.remove(e)
+	if f.entries.empty() {
+		c.removeFreqNode(f)
+	}
+	e.freq = nil
+}
+
+// newFreqNode inserts a new frequency node after the given one.
+func (c *Cache[K, V]) newFreqNode(count uint64, after *freqNode[K, V]) *freqNode[K, V] {
+	f := &freqNode[K, V]{count: count, prev: after, next: after.next}
+	f.entries.init()
+	after.next.prev = f
+	after.next = f
+	return f
+}
+
+func (c *Cache[K, V]) removeFreqNode(f *freqNode[K, V]) {
+	f.prev.next = f.next
+	f.next.prev = f.prev
+}
@@ -0,0 +1,164 @@
+package cache_test
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/ronanh/genmap"
+	"github.com/ronanh/genmap/cache"
+)
+
+func newCache(cfg cache.Config[string, int]) *cache.Cache[string, int] {
+	return cache.New[string, int](genmap.Equal[string], genmap.NewHasher[string](), cfg)
+}
+
+func keys(c *cache.Cache[string, int]) []string {
+	var keys []string
+	for k := range c.All() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func TestCacheLRU(t *testing.T) {
+	var evicted []string
+	c := newCache(cache.Config[string, int]{
+		MaxEntries: 3,
+		OnEvict: func(k string, v int) {
+			evicted = append(evicted, k)
+		},
+	})
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	c.Get("a")
+	c.Put("d", 4)
+	if !reflect.DeepEqual(evicted, []string{"b"}) {
+		t.Errorf("expected b to be evicted, got %v", evicted)
+	}
+	if got := keys(c); !reflect.DeepEqual(got, []string{"c", "a", "d"}) {
+		t.Errorf("unexpected keys %v", got)
+	}
+	if _, ok := c.Get("b"); ok {
+		t.Errorf("unexpected evicted key b")
+	}
+	if v, ok := c.Peek("c"); !ok || v != 3 {
+		t.Errorf("expected value 3, got %v", v)
+	}
+	c.Put("c", 5) // replacing counts as an access
+	c.Put("e", 6)
+	if !reflect.DeepEqual(evicted, []string{"b", "a"}) {
+		t.Errorf("expected a to be evicted, got %v", evicted)
+	}
+
+	stats := c.Stats()
+	if stats.Hits != 1 || stats.Misses != 1 || stats.Evictions != 2 || stats.HitRatio() != 0.5 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+
+	if !c.Remove("c") || c.Remove("c") {
+		t.Errorf("expected c to be removed once")
+	}
+	if c.Len() != 2 || c.Cost() != 2 {
+		t.Errorf("expected 2 entries of cost 2, got %d entries of cost %d", c.Len(), c.Cost())
+	}
+	c.Clear()
+	if c.Len() != 0 || c.Cost() != 0 || len(keys(c)) != 0 {
+		t.Errorf("expected empty cache")
+	}
+}
+
+func TestCacheLFU(t *testing.T) {
+	var evicted []string
+	c := newCache(cache.Config[string, int]{
+		MaxEntries: 3,
+		Policy:     cache.LFU,
+		OnEvict: func(k string, v int) {
+			evicted = append(evicted, k)
+		},
+	})
+	c.Put("a", 1)
+	c.Put("b", 2)
+	c.Put("c", 3)
+	c.Get("a")
+	c.Get("a")
+	c.Get("b")
+	c.Get("c")
+	c.Get("c")
+	c.Put("d", 4) // evicts b, the least frequently used
+	c.Put("e", 5) // evicts d, used once like e but less recently
+	if !reflect.DeepEqual(evicted, []string{"b", "d"}) {
+		t.Errorf("unexpected evictions %v", evicted)
+	}
+	if got := keys(c); !reflect.DeepEqual(got, []string{"e", "a", "c"}) {
+		t.Errorf("unexpected keys %v", got)
+	}
+	c.Remove("a")
+	if got := keys(c); !reflect.DeepEqual(got, []string{"e", "c"}) {
+		t.Errorf("unexpected keys %v", got)
+	}
+}
+
+func TestCacheMaxCost(t *testing.T) {
+	var evicted []string
+	c := newCache(cache.Config[string, int]{
+		MaxCost: 10,
+		OnEvict: func(k string, v int) {
+			evicted = append(evicted, k)
+		},
+		Cost: func(k string, v int) int64 {
+			return int64(v)
+		},
+	})
+	c.Put("a", 4)
+	c.Put("b", 4)
+	c.Put("c", 4) // evicts a
+	if got := keys(c); !reflect.DeepEqual(got, []string{"b", "c"}) {
+		t.Errorf("unexpected keys %v", got)
+	}
+	c.Put("b", 1)
+	if c.Cost() != 5 {
+		t.Errorf("expected cost 5, got %d", c.Cost())
+	}
+	c.Put("d", 20) // too expensive, only evicts itself
+	if got := keys(c); !reflect.DeepEqual(got, []string{"c", "b"}) || c.Cost() != 5 {
+		t.Errorf("unexpected keys %v with cost %d", got, c.Cost())
+	}
+	if !reflect.DeepEqual(evicted, []string{"a", "d"}) {
+		t.Errorf("unexpected evictions %v", evicted)
+	}
+	c.Put("b", 20) // replaced by a too expensive value
+	if got := keys(c); !reflect.DeepEqual(got, []string{"c"}) || c.Cost() != 4 {
+		t.Errorf("unexpected keys %v with cost %d", got, c.Cost())
+	}
+}
+
+func TestCacheSliceKeys(t *testing.T) {
+	hasher := genmap.NewHasher[string]()
+	c := cache.New[[]string, int](slices.Equal[[]string], func(k []string) uint64 {
+		h := genmap.HashSeed
+		for _, s := range k {
+			h = genmap.CombineHash(h, hasher(s))
+		}
+		return h
+	}, cache.Config[[]string, int]{MaxEntries: 1})
+	c.Put([]string{"a", "b"}, 1)
+	if v, ok := c.Get([]string{"a", "b"}); !ok || v != 1 {
+		t.Errorf("expected value 1, got %v", v)
+	}
+	c.Put([]string{"c"}, 2)
+	if _, ok := c.Get([]string{"a", "b"}); ok {
+		t.Errorf("unexpected evicted key")
+	}
+}
+
+func BenchmarkCacheLRU(b *testing.B) {
+	c := cache.New[int, int](genmap.Equal[int], genmap.NewHasher[int](), cache.Config[int, int]{MaxEntries: 1000})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, ok := c.Get(i % 2000); !ok {
+			c.Put(i%2000, i)
+		}
+	}
+}
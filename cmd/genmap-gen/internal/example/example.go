@@ -0,0 +1,27 @@
+// Package example holds key types whose hash and equality functions are
+// generated by genmap-gen.
+package example
+
+import "time"
+
+//go:generate go run github.com/ronanh/genmap/cmd/genmap-gen -type Key,route
+
+// Key is a key type with fields that cannot be compared with ==.
+type Key struct {
+	ID      int
+	Name    string
+	Tags    []string `genmap:"set"`
+	Path    []string
+	Attrs   map[string]int
+	Limits  [2]float64
+	Created time.Time
+	Route   route
+	Routes  []route `genmap:"set"`
+	Cache   []byte  `genmap:"-"`
+}
+
+// route is a comparable struct with an ignored field.
+type route struct {
+	From, To string
+	hits     int `genmap:"-"`
+}
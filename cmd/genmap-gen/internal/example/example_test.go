@@ -0,0 +1,84 @@
+package example_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ronanh/genmap"
+	"github.com/ronanh/genmap/cmd/genmap-gen/internal/example"
+)
+
+func newKey() example.Key {
+	return example.Key{
+		ID:      1,
+		Name:    "a",
+		Tags:    []string{"x", "y"},
+		Path:    []string{"p", "q"},
+		Attrs:   map[string]int{"a": 1, "b": 2},
+		Limits:  [2]float64{1, 2},
+		Created: time.Unix(10, 0),
+		Cache:   []byte("cache"),
+	}
+}
+
+func TestGeneratedEqual(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(k *example.Key)
+		equal  bool
+	}{
+		{"same", func(k *example.Key) {}, true},
+		{"ignored field", func(k *example.Key) { k.Cache = nil }, true},
+		{"set order", func(k *example.Key) { k.Tags = []string{"y", "x"} }, true},
+		{"set duplicates", func(k *example.Key) { k.Tags = []string{"y", "x", "y"} }, true},
+		{"set element", func(k *example.Key) { k.Tags = []string{"x", "z"} }, false},
+		{"set subset", func(k *example.Key) { k.Tags = []string{"x"} }, false},
+		{"slice order", func(k *example.Key) { k.Path = []string{"q", "p"} }, false},
+		{"map", func(k *example.Key) { k.Attrs = map[string]int{"a": 1, "b": 3} }, false},
+		{"map size", func(k *example.Key) { k.Attrs = map[string]int{"a": 1} }, false},
+		{"array", func(k *example.Key) { k.Limits[1] = 3 }, false},
+		{"int", func(k *example.Key) { k.ID = 2 }, false},
+		{"time", func(k *example.Key) { k.Created = time.Unix(11, 0) }, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a, b := newKey(), newKey()
+			test.modify(&b)
+			if got := example.EqualKey(a, b); got != test.equal {
+				t.Fatalf("EqualKey = %v, want %v", got, test.equal)
+			}
+			if test.equal && example.HashKey(a) != example.HashKey(b) {
+				t.Fatal("equal keys have different hashes")
+			}
+			if !test.equal && example.HashKey(a) == example.HashKey(b) {
+				t.Error("different keys have the same hash")
+			}
+		})
+	}
+}
+
+func TestGeneratedMap(t *testing.T) {
+	m := genmap.NewMap[example.Key, int](example.EqualKey, example.HashKey)
+	for i := range 100 {
+		k := newKey()
+		k.ID = i
+		m.Put(k, i)
+	}
+	k := newKey()
+	k.ID = 42
+	k.Tags = []string{"y", "x"}
+	if v, ok := m.Get(k); !ok || v != 42 {
+		t.Errorf("Get = %v, %v, want 42, true", v, ok)
+	}
+}
+
+func TestGeneratedHashAllocs(t *testing.T) {
+	k := newKey()
+	allocs := testing.AllocsPerRun(100, func() {
+		example.HashKey(k)
+		example.EqualKey(k, k)
+	})
+	if allocs != 0 {
+		t.Errorf("got %v allocations, want 0", allocs)
+	}
+}
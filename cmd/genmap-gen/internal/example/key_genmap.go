@@ -0,0 +1,145 @@
+// Code generated by genmap-gen; DO NOT EDIT.
+
+package example
+
+import (
+	"github.com/ronanh/genmap"
+	"time"
+)
+
+var (
+	genmapHasher_int            = genmap.NewHasher[int]()
+	genmapHasher_string         = genmap.NewHasher[string]()
+	genmapHasher_Array2_float64 = genmap.NewHasher[[2]float64]()
+	genmapHasher_time_Time      = genmap.NewHasher[time.Time]()
+)
+
+// HashKey returns the hash of v, consistent with EqualKey.
+func HashKey(v Key) uint64 {
+	var h1 uint64
+	for i2, e3 := range v.Tags {
+		// only hash the first occurrence of each element
+		j4 := 0
+		for j4 < i2 && e3 != v.Tags[j4] {
+			j4++
+		}
+		if j4 == i2 {
+			// the sum does not depend on the order
+			h1 += genmap.CombineHash(genmap.HashSeed, genmapHasher_string(e3))
+		}
+	}
+	h5 := genmap.HashSeed
+	for _, e6 := range v.Path {
+		h5 = genmap.CombineHash(h5, genmapHasher_string(e6))
+	}
+	var h7 uint64
+	for k8, v9 := range v.Attrs {
+		// the sum does not depend on the iteration order
+		h7 += genmap.CombineHash(genmapHasher_string(k8), genmapHasher_int(v9))
+	}
+	var h10 uint64
+	for i11, e12 := range v.Routes {
+		// only hash the first occurrence of each element
+		j13 := 0
+		for j13 < i11 && !equalRoute(e12, v.Routes[j13]) {
+			j13++
+		}
+		if j13 == i11 {
+			// the sum does not depend on the order
+			h10 += genmap.CombineHash(genmap.HashSeed, hashRoute(e12))
+		}
+	}
+	return genmap.CombineHashes(genmapHasher_int(v.ID), genmapHasher_string(v.Name), genmap.CombineHash(genmap.HashSeed, h1), h5, genmap.CombineHash(genmap.HashSeed, h7), genmapHasher_Array2_float64(v.Limits), genmapHasher_time_Time(v.Created), hashRoute(v.Route), genmap.CombineHash(genmap.HashSeed, h10))
+}
+
+// EqualKey reports whether a and b are equal.
+func EqualKey(a, b Key) bool {
+	if a.ID != b.ID {
+		return false
+	}
+	if a.Name != b.Name {
+		return false
+	}
+	for _, e1 := range a.Tags {
+		j2 := 0
+		for j2 < len(b.Tags) && e1 != b.Tags[j2] {
+			j2++
+		}
+		if j2 == len(b.Tags) {
+			return false
+		}
+	}
+	for _, e3 := range b.Tags {
+		j4 := 0
+		for j4 < len(a.Tags) && e3 != a.Tags[j4] {
+			j4++
+		}
+		if j4 == len(a.Tags) {
+			return false
+		}
+	}
+	if len(a.Path) != len(b.Path) {
+		return false
+	}
+	for i5 := range a.Path {
+		if a.Path[i5] != b.Path[i5] {
+			return false
+		}
+	}
+	if len(a.Attrs) != len(b.Attrs) {
+		return false
+	}
+	for k6, v7 := range a.Attrs {
+		bv8, ok9 := b.Attrs[k6]
+		if !ok9 {
+			return false
+		}
+		if v7 != bv8 {
+			return false
+		}
+	}
+	if a.Limits != b.Limits {
+		return false
+	}
+	if a.Created != b.Created {
+		return false
+	}
+	if !equalRoute(a.Route, b.Route) {
+		return false
+	}
+	for _, e10 := range a.Routes {
+		j11 := 0
+		for j11 < len(b.Routes) && !equalRoute(e10, b.Routes[j11]) {
+			j11++
+		}
+		if j11 == len(b.Routes) {
+			return false
+		}
+	}
+	for _, e12 := range b.Routes {
+		j13 := 0
+		for j13 < len(a.Routes) && !equalRoute(e12, a.Routes[j13]) {
+			j13++
+		}
+		if j13 == len(a.Routes) {
+			return false
+		}
+	}
+	return true
+}
+
+// hashRoute returns the hash of v, consistent with equalRoute.
+func hashRoute(v route) uint64 {
+	return genmap.CombineHashes(genmapHasher_string(v.From), genmapHasher_string(v.To))
+}
+
+// equalRoute reports whether a and b are equal.
+func equalRoute(a, b route) bool {
+	if a.From != b.From {
+		return false
+	}
+	if a.To != b.To {
+		return false
+	}
+	return true
+}
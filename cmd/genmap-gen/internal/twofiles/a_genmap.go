@@ -0,0 +1,76 @@
+// Code generated by genmap-gen; DO NOT EDIT.
+
+package twofiles
+
+import (
+	"github.com/ronanh/genmap"
+)
+
+var (
+	genmapHasher_string = genmap.NewHasher[string]()
+)
+
+// HashA returns the hash of v, consistent with EqualA.
+func HashA(v A) uint64 {
+	h1 := genmap.HashSeed
+	for _, e2 := range v.Labels {
+		h1 = genmap.CombineHash(h1, hashLabel(e2))
+	}
+	return genmap.CombineHashes(genmapHasher_string(v.Name), h1)
+}
+
+// EqualA reports whether a and b are equal.
+func EqualA(a, b A) bool {
+	if a.Name != b.Name {
+		return false
+	}
+	if len(a.Labels) != len(b.Labels) {
+		return false
+	}
+	for i1 := range a.Labels {
+		if !equalLabel(a.Labels[i1], b.Labels[i1]) {
+			return false
+		}
+	}
+	return true
+}
+
+// hashLabel returns the hash of v, consistent with equalLabel.
+func hashLabel(v label) uint64 {
+	var h1 uint64
+	for i2, e3 := range v.Values {
+		// only hash the first occurrence of each element
+		j4 := 0
+		for j4 < i2 && e3 != v.Values[j4] {
+			j4++
+		}
+		if j4 == i2 {
+			// the sum does not depend on the order
+			h1 += genmap.CombineHash(genmap.HashSeed, genmapHasher_string(e3))
+		}
+	}
+	return genmap.CombineHashes(genmap.CombineHash(genmap.HashSeed, h1))
+}
+
+// equalLabel reports whether a and b are equal.
+func equalLabel(a, b label) bool {
+	for _, e1 := range a.Values {
+		j2 := 0
+		for j2 < len(b.Values) && e1 != b.Values[j2] {
+			j2++
+		}
+		if j2 == len(b.Values) {
+			return false
+		}
+	}
+	for _, e3 := range b.Values {
+		j4 := 0
+		for j4 < len(a.Values) && e3 != a.Values[j4] {
+			j4++
+		}
+		if j4 == len(a.Values) {
+			return false
+		}
+	}
+	return true
+}
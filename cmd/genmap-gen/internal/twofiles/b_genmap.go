@@ -0,0 +1,23 @@
+// Code generated by genmap-gen; DO NOT EDIT.
+
+package twofiles
+
+import (
+	"github.com/ronanh/genmap"
+)
+
+// HashB returns the hash of v, consistent with EqualB.
+func HashB(v B) uint64 {
+	return genmap.CombineHashes(genmapHasher_string(v.ID), hashLabel(v.Label))
+}
+
+// EqualB reports whether a and b are equal.
+func EqualB(a, b B) bool {
+	if a.ID != b.ID {
+		return false
+	}
+	if !equalLabel(a.Label, b.Label) {
+		return false
+	}
+	return true
+}
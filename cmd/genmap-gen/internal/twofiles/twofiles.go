@@ -0,0 +1,24 @@
+// Package twofiles holds key types whose functions are generated in separate
+// files, sharing a field type.
+package twofiles
+
+//go:generate go run github.com/ronanh/genmap/cmd/genmap-gen -type A -output a_genmap.go
+//go:generate go run github.com/ronanh/genmap/cmd/genmap-gen -type B -output b_genmap.go
+
+// A has its functions generated in a_genmap.go.
+type A struct {
+	Name   string
+	Labels []label
+}
+
+// B has its functions generated in b_genmap.go, which uses the string hasher
+// and the label functions declared in a_genmap.go.
+type B struct {
+	ID    string
+	Label label
+}
+
+// label is hashed with generated functions since it is not comparable.
+type label struct {
+	Values []string `genmap:"set"`
+}
@@ -0,0 +1,531 @@
+// Command genmap-gen generates hash and equality functions for key types of
+// genmap maps.
+//
+// For each type T given with -type, it generates:
+//
+//	func HashT(v T) uint64
+//	func EqualT(a, b T) bool
+//
+// (hashT and equalT if T is unexported). The functions are composed from
+// genmap.NewHasher, genmap.CombineHash and genmap.CombineHashes, and do not
+// allocate. They are consistent with each other: equal values have the same hash.
+//
+// Struct fields can be tagged to customize the generated functions:
+//
+//	genmap:"-"    the field is ignored
+//	genmap:"set"  the slice is compared as a set: the order of the elements
+//	              and their duplicates are ignored. The elements must be
+//	              comparable or a struct type of the same package.
+//
+// Named struct types of the same package used by fields are compared with
+// their own generated functions, which are generated as well. Pointers are
+// compared by identity, like with ==.
+//
+// The functions and variables already declared in other files of the package,
+// for instance generated for other types, are not generated again.
+//
+// Usage:
+//
+//	//go:generate go run github.com/ronanh/genmap/cmd/genmap-gen -type MyKey
+package main
+
+import (
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/format"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"hash/fnv"
+	"os"
+	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+func main() {
+	typeNames := flag.String("type", "", "comma-separated list of type names; must be set")
+	output := flag.String("output", "", "output file name; default <dir>/<type>_genmap.go")
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: genmap-gen -type T [-output file] [dir]\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if *typeNames == "" || flag.NArg() > 1 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	dir := "."
+	if flag.NArg() == 1 {
+		dir = flag.Arg(0)
+	}
+	names := strings.Split(*typeNames, ",")
+	outputName := *output
+	if outputName == "" {
+		outputName = filepath.Join(dir, strings.ToLower(names[0])+"_genmap.go")
+	}
+
+	src, err := generate(dir, filepath.Base(outputName), names)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "genmap-gen: %v\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(outputName, src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "genmap-gen: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// generate returns the source of the hash and equality functions of the given
+// types of the package in dir. The file named output, if any, is not loaded.
+func generate(dir, output string, typeNames []string) ([]byte, error) {
+	pkg, err := loadPackage(dir, output)
+	if err != nil {
+		return nil, err
+	}
+	g := newGenerator(pkg)
+	for _, name := range typeNames {
+		obj, ok := pkg.Scope().Lookup(name).(*types.TypeName)
+		if !ok {
+			return nil, fmt.Errorf("type %s not found in %s", name, dir)
+		}
+		named, ok := obj.Type().(*types.Named)
+		if !ok {
+			return nil, fmt.Errorf("%s is not a named type", name)
+		}
+		g.enqueue(named)
+	}
+	return g.generate()
+}
+
+// loadPackage parses and type checks the package in dir, skipping the
+// output file. Type errors are ignored, since the package may refer to the
+// functions not generated yet.
+func loadPackage(dir, output string) (*types.Package, error) {
+	bp, err := build.ImportDir(dir, 0)
+	if err != nil {
+		return nil, err
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		if name == output {
+			continue
+		}
+		f, err := parser.ParseFile(fset, filepath.Join(dir, name), nil, 0)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{
+		Importer: importer.ForCompiler(fset, "source", nil),
+		Error:    func(error) {},
+	}
+	pkg, _ := conf.Check(bp.ImportPath, fset, files, nil)
+	return pkg, nil
+}
+
+// generator writes the functions of the queued types.
+type generator struct {
+	pkg     *types.Package
+	body    strings.Builder
+	imports map[string]string // path -> name
+	hashers map[string]string // type -> hasher variable
+	vars    []string          // hasher variables declarations
+	queue   []*types.Named
+	queued  map[*types.Named]bool
+	tmp     int
+	err     error
+}
+
+func newGenerator(pkg *types.Package) *generator {
+	return &generator{
+		pkg:     pkg,
+		imports: map[string]string{"github.com/ronanh/genmap": "genmap"},
+		hashers: make(map[string]string),
+		queued:  make(map[*types.Named]bool),
+	}
+}
+
+func (g *generator) generate() ([]byte, error) {
+	for len(g.queue) > 0 {
+		named := g.queue[0]
+		g.queue = g.queue[1:]
+		if g.declared(funcName("Hash", named)) && g.declared(funcName("Equal", named)) {
+			// generated in another file of the package, for a type sharing
+			// the same field type
+			continue
+		}
+		g.genHashFunc(named)
+		g.genEqualFunc(named)
+	}
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	var src strings.Builder
+	fmt.Fprintf(&src, "// Code generated by genmap-gen; DO NOT EDIT.\n\n")
+	fmt.Fprintf(&src, "package %s\n\nimport (\n", g.pkg.Name())
+	if len(g.vars) == 0 && !strings.Contains(g.body.String(), "genmap.") {
+		// all the hasher variables are declared in other files
+		delete(g.imports, "github.com/ronanh/genmap")
+	}
+	paths := make([]string, 0, len(g.imports))
+	for p := range g.imports {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		if name := g.imports[p]; name != path.Base(p) {
+			fmt.Fprintf(&src, "\t%s %q\n", name, p)
+		} else {
+			fmt.Fprintf(&src, "\t%q\n", p)
+		}
+	}
+	fmt.Fprintf(&src, ")\n")
+	if len(g.vars) > 0 {
+		fmt.Fprintf(&src, "\nvar (\n")
+		for _, v := range g.vars {
+			fmt.Fprintf(&src, "\t%s\n", v)
+		}
+		fmt.Fprintf(&src, ")\n")
+	}
+	fmt.Fprintf(&src, "%s", g.body.String())
+	return format.Source([]byte(src.String()))
+}
+
+func (g *generator) printf(format string, args ...any) {
+	fmt.Fprintf(&g.body, format, args...)
+}
+
+func (g *generator) fail(format string, args ...any) {
+	if g.err == nil {
+		g.err = fmt.Errorf(format, args...)
+	}
+}
+
+// newVar returns a new temporary variable name.
+func (g *generator) newVar(prefix string) string {
+	g.tmp++
+	return fmt.Sprintf("%s%d", prefix, g.tmp)
+}
+
+// typeString returns the type as written in the generated file, recording
+// the imports needed.
+func (g *generator) typeString(t types.Type) string {
+	return types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		g.imports[p.Path()] = p.Name()
+		return p.Name()
+	})
+}
+
+func (g *generator) enqueue(named *types.Named) {
+	if !g.queued[named] {
+		g.queued[named] = true
+		g.queue = append(g.queue, named)
+	}
+}
+
+// funcName returns the name of the generated function for the type.
+func funcName(prefix string, named *types.Named) string {
+	name := named.Obj().Name()
+	if !named.Obj().Exported() {
+		prefix = strings.ToLower(prefix[:1]) + prefix[1:]
+	}
+	return prefix + strings.ToUpper(name[:1]) + name[1:]
+}
+
+// hasGeneratedFuncs reports whether values of type t are hashed and compared
+// with generated functions: named struct types of the package which cannot
+// be hashed with genmap.NewHasher.
+func (g *generator) hasGeneratedFuncs(t types.Type) (*types.Named, bool) {
+	named, ok := t.(*types.Named)
+	if !ok || named.Obj().Pkg() != g.pkg {
+		return nil, false
+	}
+	if _, ok := named.Underlying().(*types.Struct); !ok || !custom(t) {
+		return nil, false
+	}
+	return named, true
+}
+
+// custom reports whether values of type t cannot be hashed with
+// genmap.NewHasher and compared with ==: t is not comparable or has fields
+// with genmap tags.
+func custom(t types.Type) bool {
+	if !types.Comparable(t) {
+		return true
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Array:
+		return custom(u.Elem())
+	case *types.Struct:
+		for i := 0; i < u.NumFields(); i++ {
+			if tag(u, i) != "" || custom(u.Field(i).Type()) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func tag(st *types.Struct, i int) string {
+	return reflect.StructTag(st.Tag(i)).Get("genmap")
+}
+
+func (g *generator) genHashFunc(named *types.Named) {
+	g.tmp = 0
+	name := funcName("Hash", named)
+	g.printf("\n// %s returns the hash of v, consistent with %s.\n", name, funcName("Equal", named))
+	g.printf("func %s(v %s) uint64 {\n", name, g.typeString(named))
+	if st, ok := named.Underlying().(*types.Struct); ok {
+		g.printf("return %s\n", g.hashStruct(st, "v"))
+	} else {
+		g.printf("return %s\n", g.hashOf(named.Underlying(), "v"))
+	}
+	g.printf("}\n")
+}
+
+func (g *generator) genEqualFunc(named *types.Named) {
+	g.tmp = 0
+	name := funcName("Equal", named)
+	g.printf("\n// %s reports whether a and b are equal.\n", name)
+	g.printf("func %s(a, b %s) bool {\n", name, g.typeString(named))
+	if st, ok := named.Underlying().(*types.Struct); ok {
+		g.equalStruct(st, "a", "b")
+	} else {
+		g.equal(named.Underlying(), "a", "b")
+	}
+	g.printf("return true\n}\n")
+}
+
+// declared reports whether name is declared in the package, outside of the
+// output file.
+func (g *generator) declared(name string) bool {
+	return g.pkg.Scope().Lookup(name) != nil
+}
+
+// hasher returns the name of the hasher variable of a comparable type.
+// The name is derived from the type, so that the files generated separately
+// in a package share their hasher variables instead of redeclaring them.
+func (g *generator) hasher(t types.Type) string {
+	typ := types.TypeString(t, func(p *types.Package) string {
+		if p == g.pkg {
+			return ""
+		}
+		return p.Name()
+	})
+	if v, ok := g.hashers[typ]; ok {
+		return v
+	}
+	v := hasherName(typ)
+	g.hashers[typ] = v
+	if obj := g.pkg.Scope().Lookup(v); obj != nil {
+		// declared by another generated file
+		sig, ok := obj.Type().Underlying().(*types.Signature)
+		if !ok || sig.Params().Len() != 1 || !types.Identical(sig.Params().At(0).Type(), t) {
+			g.fail("%s is already declared in the package", v)
+		}
+		return v
+	}
+	g.vars = append(g.vars, fmt.Sprintf("%s = genmap.NewHasher[%s]()", v, g.typeString(t)))
+	return v
+}
+
+// hasherName returns the name of the hasher variable of the type written typ,
+// for instance genmapHasher_time_Time for time.Time.
+func hasherName(typ string) string {
+	var b strings.Builder
+	b.WriteString("genmapHasher_")
+	for _, r := range typ {
+		switch {
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == '.' || r == ']':
+			b.WriteByte('_')
+		case r == '*':
+			b.WriteString("Ptr")
+		case r == '[':
+			b.WriteString("Array")
+		default:
+			// literal struct types and the like: a digest of the type avoids
+			// ambiguous names
+			h := fnv.New64a()
+			h.Write([]byte(typ))
+			return fmt.Sprintf("genmapHasher_%x", h.Sum64())
+		}
+	}
+	return b.String()
+}
+
+// hashStruct writes the statements needed to hash the fields of the struct
+// expr and returns the expression of its hash.
+func (g *generator) hashStruct(st *types.Struct, expr string) string {
+	var hashes []string
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		fieldExpr := expr + "." + f.Name()
+		switch tag(st, i) {
+		case "-":
+			continue
+		case "set":
+			hashes = append(hashes, g.hashSet(f.Type(), fieldExpr))
+		case "":
+			if f.Name() == "_" {
+				continue
+			}
+			hashes = append(hashes, g.hashOf(f.Type(), fieldExpr))
+		default:
+			g.fail("field %s: unknown genmap tag %q", f.Name(), tag(st, i))
+		}
+	}
+	if len(hashes) == 0 {
+		return "genmap.HashSeed"
+	}
+	return "genmap.CombineHashes(" + strings.Join(hashes, ", ") + ")"
+}
+
+// hashOf writes the statements needed to hash expr of type t and returns the
+// expression of its hash.
+func (g *generator) hashOf(t types.Type, expr string) string {
+	if named, ok := g.hasGeneratedFuncs(t); ok {
+		g.enqueue(named)
+		return funcName("Hash", named) + "(" + expr + ")"
+	}
+	if !custom(t) {
+		return g.hasher(t) + "(" + expr + ")"
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		return g.hashSequence(u.Elem(), expr)
+	case *types.Array:
+		return g.hashSequence(u.Elem(), expr)
+	case *types.Map:
+		h, k, v := g.newVar("h"), g.newVar("k"), g.newVar("v")
+		g.printf("var %s uint64\n", h)
+		g.printf("for %s, %s := range %s {\n", k, v, expr)
+		g.printf("// the sum does not depend on the iteration order\n")
+		g.printf("%s += genmap.CombineHash(%s, %s)\n}\n", h, g.hashOf(u.Key(), k), g.hashOf(u.Elem(), v))
+		return "genmap.CombineHash(genmap.HashSeed, " + h + ")"
+	case *types.Struct:
+		return g.hashStruct(u, expr)
+	}
+	g.fail("unsupported type %s", g.typeString(t))
+	return ""
+}
+
+func (g *generator) hashSequence(elem types.Type, expr string) string {
+	h, e := g.newVar("h"), g.newVar("e")
+	g.printf("%s := genmap.HashSeed\n", h)
+	g.printf("for _, %s := range %s {\n", e, expr)
+	g.printf("%s = genmap.CombineHash(%s, %s)\n}\n", h, h, g.hashOf(elem, e))
+	return h
+}
+
+// hashSet hashes the slice expr ignoring the order and duplicates of its elements.
+func (g *generator) hashSet(t types.Type, expr string) string {
+	s, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		g.fail("genmap:\"set\" tag on non slice field %s", expr)
+		return ""
+	}
+	h, i, e, j := g.newVar("h"), g.newVar("i"), g.newVar("e"), g.newVar("j")
+	g.printf("var %s uint64\n", h)
+	g.printf("for %s, %s := range %s {\n", i, e, expr)
+	g.printf("// only hash the first occurrence of each element\n")
+	g.printf("%s := 0\n", j)
+	g.printf("for %s < %s && %s {\n%s++\n}\n", j, i, g.notEqualExpr(s.Elem(), e, expr+"["+j+"]"), j)
+	g.printf("if %s == %s {\n", j, i)
+	g.printf("// the sum does not depend on the order\n")
+	g.printf("%s += genmap.CombineHash(genmap.HashSeed, %s)\n}\n}\n", h, g.hashOf(s.Elem(), e))
+	return "genmap.CombineHash(genmap.HashSeed, " + h + ")"
+}
+
+// equalStruct writes the statements returning false if the structs a and b differ.
+func (g *generator) equalStruct(st *types.Struct, a, b string) {
+	for i := 0; i < st.NumFields(); i++ {
+		f := st.Field(i)
+		fa, fb := a+"."+f.Name(), b+"."+f.Name()
+		switch tag(st, i) {
+		case "-":
+		case "set":
+			g.equalSet(f.Type(), fa, fb)
+		default:
+			if f.Name() != "_" {
+				g.equal(f.Type(), fa, fb)
+			}
+		}
+	}
+}
+
+// equal writes the statements returning false if a and b of type t differ.
+func (g *generator) equal(t types.Type, a, b string) {
+	if _, ok := g.hasGeneratedFuncs(t); ok || !custom(t) {
+		g.printf("if %s {\nreturn false\n}\n", g.notEqualExpr(t, a, b))
+		return
+	}
+	switch u := t.Underlying().(type) {
+	case *types.Slice:
+		g.printf("if len(%s) != len(%s) {\nreturn false\n}\n", a, b)
+		g.equalSequence(u.Elem(), a, b)
+	case *types.Array:
+		g.equalSequence(u.Elem(), a, b)
+	case *types.Map:
+		k, v, bv, ok := g.newVar("k"), g.newVar("v"), g.newVar("bv"), g.newVar("ok")
+		g.printf("if len(%s) != len(%s) {\nreturn false\n}\n", a, b)
+		g.printf("for %s, %s := range %s {\n", k, v, a)
+		g.printf("%s, %s := %s[%s]\nif !%s {\nreturn false\n}\n", bv, ok, b, k, ok)
+		g.equal(u.Elem(), v, bv)
+		g.printf("}\n")
+	case *types.Struct:
+		g.equalStruct(u, a, b)
+	default:
+		g.fail("unsupported type %s", g.typeString(t))
+	}
+}
+
+func (g *generator) equalSequence(elem types.Type, a, b string) {
+	i := g.newVar("i")
+	g.printf("for %s := range %s {\n", i, a)
+	g.equal(elem, a+"["+i+"]", b+"["+i+"]")
+	g.printf("}\n")
+}
+
+// equalSet writes the statements returning false if the slices a and b do
+// not have the same elements, ignoring order and duplicates.
+func (g *generator) equalSet(t types.Type, a, b string) {
+	s, ok := t.Underlying().(*types.Slice)
+	if !ok {
+		g.fail("genmap:\"set\" tag on non slice field %s", a)
+		return
+	}
+	for _, pair := range [][2]string{{a, b}, {b, a}} {
+		e, j := g.newVar("e"), g.newVar("j")
+		g.printf("for _, %s := range %s {\n", e, pair[0])
+		g.printf("%s := 0\n", j)
+		g.printf("for %s < len(%s) && %s {\n%s++\n}\n", j, pair[1], g.notEqualExpr(s.Elem(), e, pair[1]+"["+j+"]"), j)
+		g.printf("if %s == len(%s) {\nreturn false\n}\n}\n", j, pair[1])
+	}
+}
+
+// notEqualExpr returns a boolean expression true if a and b differ. Their
+// type must be comparable or have generated functions.
+func (g *generator) notEqualExpr(t types.Type, a, b string) string {
+	if named, ok := g.hasGeneratedFuncs(t); ok {
+		g.enqueue(named)
+		return "!" + funcName("Equal", named) + "(" + a + ", " + b + ")"
+	}
+	if custom(t) {
+		g.fail("set elements of type %s must be comparable or a struct type of the package", g.typeString(t))
+	}
+	return a + " != " + b
+}
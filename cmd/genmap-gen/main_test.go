@@ -0,0 +1,71 @@
+package main
+
+import (
+	"go/ast"
+	"go/build"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGenerateExample(t *testing.T) {
+	want, err := os.ReadFile("internal/example/key_genmap.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := generate("internal/example", "key_genmap.go", []string{"Key", "route"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("generated code differs from internal/example/key_genmap.go, run go generate:\n%s", got)
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	if _, err := generate("internal/example", "key_genmap.go", []string{"Missing"}); err == nil {
+		t.Error("expected an error for a missing type")
+	}
+}
+
+func TestGenerateSeparateFiles(t *testing.T) {
+	for _, test := range []struct{ output, typeName string }{
+		{"a_genmap.go", "A"},
+		{"b_genmap.go", "B"},
+	} {
+		want, err := os.ReadFile(filepath.Join("internal/twofiles", test.output))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := generate("internal/twofiles", test.output, []string{test.typeName})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(got) != string(want) {
+			t.Errorf("generated code differs from internal/twofiles/%s, run go generate:\n%s", test.output, got)
+		}
+	}
+
+	// the generated files do not redeclare each other's functions and variables
+	bp, err := build.ImportDir("internal/twofiles", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fset := token.NewFileSet()
+	var files []*ast.File
+	for _, name := range bp.GoFiles {
+		f, err := parser.ParseFile(fset, filepath.Join("internal/twofiles", name), nil, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files = append(files, f)
+	}
+	conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+	if _, err := conf.Check(bp.ImportPath, fset, files, nil); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,124 @@
+// Command genmap-hashstat reports the quality of a hash function, as
+// genmaptest.AnalyzeHash does.
+//
+// It reads one key per line from the given files, or the standard input, and
+// either hashes the keys itself or reads hashes computed by the program under
+// test:
+//
+//	-input keys     each line is a key hashed with genmap.NewHasher[string]
+//	-input fields   each line holds fields separated by -sep, hashed with
+//	                genmap.NewHasher[string] and combined with CombineHashes
+//	-input hashes   each line is a hash, in decimal or 0x-prefixed hexadecimal
+//
+// The keys and fields formats hash the keys with the hasher of this tool, not
+// with the hash function of the program: they only measure the entropy of the
+// key set, e.g. whether it holds duplicate keys. To evaluate a custom hash
+// function, print its hashes from the program and use -input hashes.
+//
+// The exit status is 1 if issues are found.
+//
+// Usage:
+//
+//	genmap-hashstat [-input keys|fields|hashes] [-sep ,] [-buckets n] [file...]
+package main
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/ronanh/genmap"
+	"github.com/ronanh/genmap/genmaptest"
+)
+
+func main() {
+	ok, err := run(os.Args[1:], os.Stdin, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "genmap-hashstat: %v\n", err)
+		os.Exit(2)
+	}
+	if !ok {
+		os.Exit(1)
+	}
+}
+
+// run analyzes the hashes read from the files given in args, or stdin, and
+// writes the report to stdout. It returns false if issues are found.
+func run(args []string, stdin io.Reader, stdout io.Writer) (bool, error) {
+	flags := flag.NewFlagSet("genmap-hashstat", flag.ContinueOnError)
+	input := flags.String("input", "keys", "input format: keys or fields (hashed by this tool: only measures the key set), or hashes (computed by the hash function under test)")
+	sep := flags.String("sep", ",", "field separator for -input fields")
+	buckets := flags.Int("buckets", 0, "number of buckets; default the number of keys")
+	if err := flags.Parse(args); err != nil {
+		return false, err
+	}
+
+	var parse func(line string) (uint64, error)
+	switch *input {
+	case "keys":
+		hasher := genmap.NewHasher[string]()
+		parse = func(line string) (uint64, error) {
+			return hasher(line), nil
+		}
+	case "fields":
+		hasher := genmap.NewHasher[string]()
+		parse = func(line string) (uint64, error) {
+			h := genmap.HashSeed
+			for _, field := range strings.Split(line, *sep) {
+				h = genmap.CombineHash(h, hasher(field))
+			}
+			return h, nil
+		}
+	case "hashes":
+		parse = func(line string) (uint64, error) {
+			return strconv.ParseUint(strings.TrimSpace(line), 0, 64)
+		}
+	default:
+		return false, fmt.Errorf("unknown input format %q", *input)
+	}
+
+	var hashes []uint64
+	read := func(r io.Reader) error {
+		scanner := bufio.NewScanner(r)
+		for scanner.Scan() {
+			h, err := parse(scanner.Text())
+			if err != nil {
+				return err
+			}
+			hashes = append(hashes, h)
+		}
+		return scanner.Err()
+	}
+	if flags.NArg() == 0 {
+		if err := read(stdin); err != nil {
+			return false, err
+		}
+	}
+	for _, name := range flags.Args() {
+		f, err := os.Open(name)
+		if err != nil {
+			return false, err
+		}
+		err = read(f)
+		f.Close()
+		if err != nil {
+			return false, fmt.Errorf("%s: %w", name, err)
+		}
+	}
+	if len(hashes) == 0 {
+		return false, errors.New("no input")
+	}
+
+	n := *buckets
+	if n == 0 {
+		n = len(hashes)
+	}
+	report := genmaptest.AnalyzeHashes(hashes, n)
+	fmt.Fprint(stdout, report)
+	return report.Issues() == nil, nil
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	var keys, fields, poor strings.Builder
+	for i := range 1000 {
+		fmt.Fprintf(&keys, "key%d\n", i)
+		fmt.Fprintf(&fields, "%d,%d\n", i%10, i)
+		fmt.Fprintf(&poor, "%#x\n", i%10)
+	}
+	tests := []struct {
+		args  []string
+		input string
+		ok    bool
+	}{
+		{nil, keys.String(), true},
+		{[]string{"-input", "fields", "-buckets", "64"}, fields.String(), true},
+		{[]string{"-input", "hashes"}, poor.String(), false},
+	}
+	for _, test := range tests {
+		var out strings.Builder
+		ok, err := run(test.args, strings.NewReader(test.input), &out)
+		if err != nil {
+			t.Fatalf("%v: %v", test.args, err)
+		}
+		if ok != test.ok {
+			t.Errorf("%v: got ok = %v, want %v:\n%s", test.args, ok, test.ok, out.String())
+		}
+		if !strings.HasPrefix(out.String(), "keys: 1000,") {
+			t.Errorf("%v: unexpected report:\n%s", test.args, out.String())
+		}
+	}
+}
+
+func TestRunErrors(t *testing.T) {
+	for _, args := range [][]string{{"-input", "unknown"}, {"-input", "hashes"}} {
+		if _, err := run(args, strings.NewReader("not a hash\n"), &strings.Builder{}); err == nil {
+			t.Errorf("%v: expected an error", args)
+		}
+	}
+}
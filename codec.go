@@ -0,0 +1,135 @@
+package genmap
+
+import (
+	"encoding"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// Codec encodes and decodes the keys or values of a Map for Map.WriteBinary
+// and Map.ReadBinary. The encoded values are length-prefixed in the stream,
+// so a codec does not need to delimit them.
+type Codec[T any] interface {
+	// Append appends the encoding of v to b and returns the extended buffer.
+	Append(b []byte, v T) ([]byte, error)
+	// Decode decodes a value from data, which holds exactly one encoded value.
+	// data must not be retained after Decode returns.
+	Decode(data []byte) (T, error)
+}
+
+// NewCodec returns a Codec made of the given functions.
+func NewCodec[T any](appendFunc func(b []byte, v T) ([]byte, error), decode func(data []byte) (T, error)) Codec[T] {
+	return funcCodec[T]{appendFunc, decode}
+}
+
+type funcCodec[T any] struct {
+	appendFunc func(b []byte, v T) ([]byte, error)
+	decode     func(data []byte) (T, error)
+}
+
+func (c funcCodec[T]) Append(b []byte, v T) ([]byte, error) { return c.appendFunc(b, v) }
+func (c funcCodec[T]) Decode(data []byte) (T, error)        { return c.decode(data) }
+
+// StringCodec returns a Codec for string types.
+func StringCodec[T ~string]() Codec[T] {
+	return stringCodec[T]{}
+}
+
+type stringCodec[T ~string] struct{}
+
+func (stringCodec[T]) Append(b []byte, v T) ([]byte, error) { return append(b, v...), nil }
+func (stringCodec[T]) Decode(data []byte) (T, error)        { return T(data), nil }
+
+// BytesCodec returns a Codec for byte slices.
+func BytesCodec() Codec[[]byte] {
+	return bytesCodec{}
+}
+
+type bytesCodec struct{}
+
+func (bytesCodec) Append(b []byte, v []byte) ([]byte, error) { return append(b, v...), nil }
+func (bytesCodec) Decode(data []byte) ([]byte, error)        { return append([]byte(nil), data...), nil }
+
+type signedInteger interface {
+	~int | ~int8 | ~int16 | ~int32 | ~int64
+}
+
+type unsignedInteger interface {
+	~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+}
+
+var errIntegerOverflow = errors.New("genmap: decoded integer overflows its type")
+
+// IntCodec returns a Codec for signed integer types, encoded as varints.
+func IntCodec[T signedInteger]() Codec[T] {
+	return intCodec[T]{}
+}
+
+type intCodec[T signedInteger] struct{}
+
+func (intCodec[T]) Append(b []byte, v T) ([]byte, error) {
+	return binary.AppendVarint(b, int64(v)), nil
+}
+
+func (intCodec[T]) Decode(data []byte) (T, error) {
+	x, n := binary.Varint(data)
+	if n != len(data) {
+		return 0, fmt.Errorf("%w: invalid varint", ErrInvalidFormat)
+	}
+	if int64(T(x)) != x {
+		return 0, errIntegerOverflow
+	}
+	return T(x), nil
+}
+
+// UintCodec returns a Codec for unsigned integer types, encoded as varints.
+func UintCodec[T unsignedInteger]() Codec[T] {
+	return uintCodec[T]{}
+}
+
+type uintCodec[T unsignedInteger] struct{}
+
+func (uintCodec[T]) Append(b []byte, v T) ([]byte, error) {
+	return binary.AppendUvarint(b, uint64(v)), nil
+}
+
+func (uintCodec[T]) Decode(data []byte) (T, error) {
+	x, n := binary.Uvarint(data)
+	if n != len(data) {
+		return 0, fmt.Errorf("%w: invalid varint", ErrInvalidFormat)
+	}
+	if uint64(T(x)) != x {
+		return 0, errIntegerOverflow
+	}
+	return T(x), nil
+}
+
+// BinaryCodec returns a Codec for types implementing encoding.BinaryMarshaler,
+// and encoding.BinaryUnmarshaler through a pointer.
+func BinaryCodec[T any]() Codec[T] {
+	return binaryCodec[T]{}
+}
+
+type binaryCodec[T any] struct{}
+
+func (binaryCodec[T]) Append(b []byte, v T) ([]byte, error) {
+	m, ok := any(v).(encoding.BinaryMarshaler)
+	if !ok {
+		return b, fmt.Errorf("genmap: %v does not implement encoding.BinaryMarshaler", reflect.TypeFor[T]())
+	}
+	data, err := m.MarshalBinary()
+	return append(b, data...), err
+}
+
+func (binaryCodec[T]) Decode(data []byte) (T, error) {
+	var v T
+	u, ok := any(&v).(encoding.BinaryUnmarshaler)
+	if !ok {
+		return v, fmt.Errorf("genmap: %v does not implement encoding.BinaryUnmarshaler", reflect.PointerTo(reflect.TypeFor[T]()))
+	}
+	// the unmarshaler may retain the data
+	err := u.UnmarshalBinary(append([]byte(nil), data...))
+	return v, err
+}
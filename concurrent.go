@@ -0,0 +1,188 @@
+package genmap
+
+import (
+	"iter"
+	"math/bits"
+	"runtime"
+	"sync"
+	"unsafe"
+)
+
+// ConcurrentMap is a map safe for concurrent use by multiple goroutines.
+// Keys are partitioned by their hash into shards, each shard being a Map
+// protected by its own lock.
+// ConcurrentMap instance should be instantiated using the NewConcurrentMap function.
+type ConcurrentMap[K any, V any] struct {
+	hash   func(k K) uint64
+	shift  uint // 64 - log2(number of shards)
+	shards []concurrentShard[K, V]
+}
+
+type concurrentShard[K any, V any] struct {
+	sync.RWMutex
+	m *Map[K, V]
+	// pad the shards to 128 bytes: whatever the alignment of the shards, the
+	// locks of two shards never share a 64-byte cache line
+	_ [128 - unsafe.Sizeof(sync.RWMutex{}) - unsafe.Sizeof(uintptr(0))]byte
+}
+
+// NewConcurrentMap returns a new instance of ConcurrentMap[K, V] with the given
+// equality and hash functions.
+// The number of shards is rounded up to a power of 2. If it is 0, a default
+// based on GOMAXPROCS is used.
+// The options configure each shard; a capacity, or the default number of
+// buckets without one, is divided among the shards.
+func NewConcurrentMap[K any, V any](equal func(k1, k2 K) bool, hash func(k K) uint64, shards int, opts ...MapOption) *ConcurrentMap[K, V] {
+	if shards < 0 {
+		panic("negative number of shards")
+	}
+	if shards == 0 {
+		shards = 4 * runtime.GOMAXPROCS(0)
+	}
+	logShards := bits.Len(uint(shards - 1))
+	shards = 1 << logShards
+
+	o := mapOptions{maxLoadFactor: defaultMaxLoadFactor}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.capacity > 0 {
+		o.capacity = (o.capacity + shards - 1) / shards
+	} else if o.bucketsSize == 0 {
+		// the shards grow as needed: split the default size rather than
+		// allocating it for each shard
+		o.bucketsSize = max(defaultBucketsSize/shards, 1)
+	}
+
+	cm := &ConcurrentMap[K, V]{
+		hash:   hash,
+		shift:  uint(64 - logShards),
+		shards: make([]concurrentShard[K, V], shards),
+	}
+	for i := range cm.shards {
+		cm.shards[i].m = newMap[K, V](equal, hash, o)
+	}
+	return cm
+}
+
+// shard returns the shard of the given hash.
+// The high bits of the hash are used, since the shard maps use the low bits.
+// The hash is mixed first (Fibonacci hashing), so that hash functions with
+// weak high bits, such as the identity on small integers, still spread the
+// keys over all the shards.
+func (cm *ConcurrentMap[K, V]) shard(hash uint64) *concurrentShard[K, V] {
+	return &cm.shards[(hash*0x9e3779b97f4a7c15)>>cm.shift]
+}
+
+// Len returns the number of elements in the map.
+// Concurrent modifications may or may not be accounted for.
+func (cm *ConcurrentMap[K, V]) Len() int {
+	n := 0
+	for i := range cm.shards {
+		s := &cm.shards[i]
+		s.RLock()
+		n += s.m.Len()
+		s.RUnlock()
+	}
+	return n
+}
+
+// Clear removes all elements from the map.
+func (cm *ConcurrentMap[K, V]) Clear() {
+	for i := range cm.shards {
+		s := &cm.shards[i]
+		s.Lock()
+		s.m.Clear()
+		s.Unlock()
+	}
+}
+
+// Get returns the value associated with the given key.
+func (cm *ConcurrentMap[K, V]) Get(key K) (V, bool) {
+	hash := cm.hash(key)
+	s := cm.shard(hash)
+	s.RLock()
+	defer s.RUnlock()
+	return s.m.get(hash, key)
+}
+
+// Put inserts the given key-value pair into the map.
+func (cm *ConcurrentMap[K, V]) Put(key K, val V) {
+	hash := cm.hash(key)
+	s := cm.shard(hash)
+	s.Lock()
+	defer s.Unlock()
+	entry := makeOptionalEntry(s.m, hash, key)
+	entry.OrDefault().elem.Value = val
+}
+
+// Remove removes the given key from the map and returns it.
+func (cm *ConcurrentMap[K, V]) Remove(key K) (MapElement[K, V], bool) {
+	hash := cm.hash(key)
+	s := cm.shard(hash)
+	s.Lock()
+	defer s.Unlock()
+	return s.m.removeHash(hash, key)
+}
+
+// Upsert inserts or modifies the given entry into the map.
+// The update function is called with the current value or the new one while
+// holding the lock of the shard, so it must not access the map.
+func (cm *ConcurrentMap[K, V]) Upsert(key K, update func(elem *MapElement[K, V], exists bool)) {
+	cm.Compute(key, func(entry *MaybeMapEntry[K, V]) {
+		exists := entry.Exists()
+		entry.OrDefault().MutateWith(func(elem *MapElement[K, V]) {
+			update(elem, exists)
+		})
+	})
+}
+
+// Compute calls f with the entry of the given key while holding the lock of
+// the shard, allowing the entry to be inspected and modified atomically.
+// f must not access the map, and the entry must not be used after f returns.
+//
+// Example:
+//
+//	// insert the key only if absent
+//	cm.Compute(key, func(entry *genmap.MaybeMapEntry[string, int]) {
+//	    if !entry.Exists() {
+//	        entry.OrDefault().MutateWith(func(e *genmap.MapElement[string, int]) {
+//	            e.Value = 1
+//	        })
+//	    }
+//	})
+func (cm *ConcurrentMap[K, V]) Compute(key K, f func(entry *MaybeMapEntry[K, V])) {
+	hash := cm.hash(key)
+	s := cm.shard(hash)
+	s.Lock()
+	defer s.Unlock()
+	entry := makeOptionalEntry(s.m, hash, key)
+	f(&entry)
+}
+
+// All returns a weakly consistent iterator over the key-value pairs of the map.
+// The shards are visited one at a time: the elements of a shard are copied
+// while holding its lock, then yielded without holding any lock, so the map
+// may be accessed and modified during the iteration. Modifications of a shard
+// not yet visited are reflected by the iteration, the others are not.
+func (cm *ConcurrentMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		var elems []MapElement[K, V]
+		for i := range cm.shards {
+			s := &cm.shards[i]
+			s.RLock()
+			elems = elems[:0]
+			it := s.m.Iterator()
+			for it.Next() {
+				// read-only access under the read lock
+				elems = append(elems, *it.cur(false))
+			}
+			s.RUnlock()
+			for j := range elems {
+				if !yield(elems[j].Key, elems[j].Value) {
+					return
+				}
+			}
+		}
+	}
+}
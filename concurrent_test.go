@@ -0,0 +1,145 @@
+package genmap_test
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func TestConcurrentMap(t *testing.T) {
+	m := genmap.NewConcurrentMap[MyKey, int](MyKeyEquals, NewMyKeyHasher(), 4)
+	m.Put(MyKey{1, []string{"a"}}, 1)
+	m.Put(MyKey{2, []string{"b"}}, 2)
+	m.Put(MyKey{1, []string{"a"}}, 3)
+	if m.Len() != 2 {
+		t.Errorf("expected length 2, got %d", m.Len())
+	}
+	if v, ok := m.Get(MyKey{1, []string{"a"}}); !ok || v != 3 {
+		t.Errorf("expected value 3, got %v", v)
+	}
+	m.Upsert(MyKey{2, []string{"b"}}, func(elem *genmap.MapElement[MyKey, int], exists bool) {
+		if !exists {
+			t.Errorf("expected existing key")
+		}
+		elem.Value++
+	})
+	if v, ok := m.Get(MyKey{2, []string{"b"}}); !ok || v != 3 {
+		t.Errorf("expected value 3, got %v", v)
+	}
+	m.Compute(MyKey{3, nil}, func(entry *genmap.MaybeMapEntry[MyKey, int]) {
+		if entry.Exists() {
+			t.Errorf("unexpected existing key")
+		}
+		entry.OrDefault().MutateWith(func(elem *genmap.MapElement[MyKey, int]) {
+			elem.Value = 4
+		})
+	})
+	if elem, ok := m.Remove(MyKey{3, nil}); !ok || elem.Value != 4 {
+		t.Errorf("expected removed value 4, got %v", elem)
+	}
+	if _, ok := m.Remove(MyKey{3, nil}); ok {
+		t.Errorf("unexpected removed key")
+	}
+
+	sum := 0
+	for _, v := range m.All() {
+		sum += v
+	}
+	if sum != 6 {
+		t.Errorf("expected sum 6, got %d", sum)
+	}
+
+	m.Clear()
+	if m.Len() != 0 {
+		t.Errorf("expected empty map, got %d elements", m.Len())
+	}
+}
+
+func TestConcurrentMapDefaultSize(t *testing.T) {
+	single := genmap.NewMapWithOptions[int, int](genmap.Equal[int], genmap.NewHasher[int]()).Stats()
+	// the default number of buckets is split among the shards
+	sharded := genmap.NewConcurrentMap[int, int](genmap.Equal[int], genmap.NewHasher[int](), 64).Stats()
+	if sharded.Buckets > 2*single.Buckets {
+		t.Errorf("expected an empty map of 64 shards to have at most %d buckets, got %d", 2*single.Buckets, sharded.Buckets)
+	}
+}
+
+func TestConcurrentMapShardSpread(t *testing.T) {
+	// the identity hash leaves the high bits of small integers unset
+	identity := func(k int) uint64 { return uint64(k) }
+	m := genmap.NewConcurrentMap[int, int](genmap.Equal[int], identity, 64,
+		genmap.WithCapacity(1024), genmap.WithGrowthPolicy(genmap.FixedSize))
+	for i := range 1024 {
+		m.Put(i, i)
+	}
+	s := m.Stats()
+	if s.Len != 1024 {
+		t.Errorf("expected 1024 elements, got %d", s.Len)
+	}
+	if s.MaxChain > 8 {
+		t.Errorf("expected the keys to be spread over the shards, got chains of up to %d elements", s.MaxChain)
+	}
+}
+
+func TestConcurrentMapParallel(t *testing.T) {
+	m := genmap.NewConcurrentMap[string, int](genmap.Equal[string], genmap.NewHasher[string](), 0,
+		genmap.WithCapacity(1000))
+	const workers = 8
+	const n = 1000
+
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := 0; i < n; i++ {
+				k := strconv.Itoa(i)
+				m.Upsert(k, func(elem *genmap.MapElement[string, int], exists bool) {
+					elem.Value++
+				})
+				m.Get(k)
+				m.Put("w"+strconv.Itoa(w)+"/"+k, i)
+				if i%2 == 0 {
+					m.Remove("w" + strconv.Itoa(w) + "/" + k)
+				}
+			}
+		}(w)
+	}
+	// iterate while the map is modified
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10; i++ {
+			for k := range m.All() {
+				m.Get(k)
+			}
+		}
+	}()
+	wg.Wait()
+
+	if m.Len() != n+workers*n/2 {
+		t.Errorf("expected length %d, got %d", n+workers*n/2, m.Len())
+	}
+	for i := 0; i < n; i++ {
+		if v, ok := m.Get(strconv.Itoa(i)); !ok || v != workers {
+			t.Errorf("expected value %d for key %d, got %v", workers, i, v)
+		}
+	}
+}
+
+func BenchmarkConcurrentMapUpsertParallel(b *testing.B) {
+	m := genmap.NewConcurrentMap[int, int](genmap.Equal[int], genmap.NewHasher[int](), 0)
+	update := func(elem *genmap.MapElement[int, int], exists bool) {
+		elem.Value++
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			m.Upsert(i%100000, update)
+			i++
+		}
+	})
+}
@@ -17,97 +17,199 @@ package genmap
 // own the element; it merely holds a pointer that can be used to mutate the
 // element via `MutateWith`.
 type MapEntry[K any, V any] struct {
-	elem *MapElement[K, V] // pointer to the underlying element
+	mods  modSnapshot       // map modification count (genmapdebug build tag only)
+	guard keyGuard[K]       // key hash (genmapdebug build tag only)
+	elem  *MapElement[K, V] // pointer to the underlying element
 }
 
 // MutateWith runs the supplied function on the underlying map element.
-// The caller can modify the key, value, or other fields directly.
+// The caller can modify the value directly. The key must not be modified, as
+// the element would not be found anymore; prefer MutateValue.
+// The entry must not be used after an insertion or removal in the map; with
+// the genmapdebug build tag, MutateWith panics if it is, or if the key was
+// modified.
 func (entry MapEntry[K, V]) MutateWith(f func(*MapElement[K, V])) {
+	entry.mods.check(errModifiedSinceEntry)
 	f(entry.elem)
+	entry.guard.check(entry.elem.Key)
+}
+
+// MutateValue runs the supplied function on the value of the underlying map
+// element.
+func (entry MapEntry[K, V]) MutateValue(f func(*V)) {
+	entry.mods.check(errModifiedSinceEntry)
+	f(&entry.elem.Value)
+}
+
+// Key returns the key of the element.
+func (entry MapEntry[K, V]) Key() K {
+	entry.mods.check(errModifiedSinceEntry)
+	return entry.elem.Key
+}
+
+// Value returns the value of the element.
+func (entry MapEntry[K, V]) Value() V {
+	entry.mods.check(errModifiedSinceEntry)
+	return entry.elem.Value
+}
+
+// ValuePtr returns a pointer to the value of the element, to modify it in
+// place. Like the entry, the pointer must not be used after an insertion or
+// removal in the map.
+func (entry MapEntry[K, V]) ValuePtr() *V {
+	entry.mods.check(errModifiedSinceEntry)
+	return &entry.elem.Value
 }
 
 // MaybeMapEntry represents the result of a lookup that may be absent.
-// It stores enough context (map reference, bucket position, hash, key) to
+// It stores enough context (map reference, hash, key) to
 // either return the found element or create a new one on demand.
 type MaybeMapEntry[K any, V any] struct {
-	m         *Map[K, V]        // reference to the parent map
-	elem      *MapElement[K, V] // nil if the key was not found
-	bucketPos uint64            // index of the bucket in the map's bucket slice
-	hash      uint64            // pre‑computed hash of the key
-	key       K                 // the key being looked up
+	m    *Map[K, V]        // reference to the parent map
+	mods modSnapshot       // map modification count (genmapdebug build tag only)
+	elem *MapElement[K, V] // nil if the key was not found
+	hash uint64            // pre‑computed hash of the key
+	key  K                 // the key being looked up
 }
 
-// makeOptionalEntry performs a lookup for `key` in map `m`.  If an element
+// makeOptionalEntry performs a lookup for `key`, whose hash is `hash`, in map
+// `m`.  If an element
 // with a matching hash and key is found, it returns a `MaybeMapEntry` that
 // points to that element.  Otherwise it returns a `MaybeMapEntry` with a nil
 // `elem`, allowing the caller to create a new entry via `OrDefault`.
-func makeOptionalEntry[K any, V any](m *Map[K, V], key K) MaybeMapEntry[K, V] {
-	hash := m.hash(key)
-	bucketPos := hash % uint64(len(m.buckets))
-	bucket := m.buckets[bucketPos]
+func makeOptionalEntry[K any, V any](m *Map[K, V], hash uint64, key K) MaybeMapEntry[K, V] {
+	if m.swiss != nil {
+		if slot := m.swiss.find(hash, key); slot >= 0 {
+			return MaybeMapEntry[K, V]{m, m.mods.snapshot(), &m.swiss.slots[slot], hash, key}
+		}
+		return MaybeMapEntry[K, V]{m, m.mods.snapshot(), nil, hash, key}
+	}
+	// the element found may be modified through the entry
+	m.ownBuckets()
+	buckets, bucketPos := m.locate(hash)
+	bucket := m.ownBucket(buckets, bucketPos)
 	if len(bucket) > 0 {
 		if bucket[0].hash == hash && m.equal(bucket[0].Key, key) {
-			return MaybeMapEntry[K, V]{m, &bucket[0], bucketPos, hash, key}
+			return MaybeMapEntry[K, V]{m, m.mods.snapshot(), &bucket[0], hash, key}
 		}
 		if len(bucket) > 1 {
 			// slow path – iterate over the rest of the bucket
 			for pos := 1; pos < len(bucket); pos++ {
 				if bucket[pos].hash == hash && m.equal(bucket[pos].Key, key) {
-					return MaybeMapEntry[K, V]{m, &bucket[pos], bucketPos, hash, key}
+					return MaybeMapEntry[K, V]{m, m.mods.snapshot(), &bucket[pos], hash, key}
 				}
 			}
 		}
 	}
 	// Not found – return a placeholder with nil element
-	return MaybeMapEntry[K, V]{m, nil, bucketPos, hash, key}
+	return MaybeMapEntry[K, V]{m, m.mods.snapshot(), nil, hash, key}
 }
 
 // Exists reports whether the lookup succeeded (i.e. an element was found).
-func (entry *MaybeMapEntry[K, V]) Exists() bool {
+func (entry MaybeMapEntry[K, V]) Exists() bool {
 	return entry.elem != nil
 }
 
+// Key returns the key being looked up.
+func (entry MaybeMapEntry[K, V]) Key() K {
+	return entry.key
+}
+
+// Value returns the value of the element, if it exists.
+func (entry MaybeMapEntry[K, V]) Value() (V, bool) {
+	if entry.elem == nil {
+		return *new(V), false
+	}
+	entry.mods.check(errModifiedSinceEntry)
+	return entry.elem.Value, true
+}
+
+// ValuePtr returns a pointer to the value of the element, nil if it does not
+// exist. The pointer must not be used after an insertion or removal in the map.
+func (entry MaybeMapEntry[K, V]) ValuePtr() *V {
+	if entry.elem == nil {
+		return nil
+	}
+	entry.mods.check(errModifiedSinceEntry)
+	return &entry.elem.Value
+}
+
 // OrDefault returns a concrete `MapEntry`.  If the element already exists it
 // is returned unchanged; otherwise a new element is allocated, inserted into
 // the appropriate bucket, and a handle to that new element is returned.
-func (entry *MaybeMapEntry[K, V]) OrDefault() MapEntry[K, V] {
+// The entry must not be used after another write to the map.
+func (entry MaybeMapEntry[K, V]) OrDefault() MapEntry[K, V] {
+	entry.mods.check(errModifiedSinceEntry)
+	guard := newKeyGuard(entry.m.hash, entry.hash)
 	if entry.elem != nil {
-		return MapEntry[K, V]{entry.elem}
+		return MapEntry[K, V]{entry.mods, guard, entry.elem}
 	}
 
-	m := entry.m
-	bucketPos := entry.bucketPos
-	hash := entry.hash
-	key := entry.key
-	bucket := m.buckets[bucketPos]
+	// The map may start or continue an incremental resize here, so the
+	// element is inserted using the cached hash rather than a bucket position.
+	elem := entry.m.insert(entry.hash, entry.key)
+	return MapEntry[K, V]{entry.m.mods.snapshot(), guard, elem}
+}
 
-	// Grow the map length to account for the new element
-	m.len++
+// OrInsert returns the existing element, or inserts one with the given value.
+//
+// Example:
+//
+//	// count the occurrences of each key
+//	m.Entry(key).AndModify(func(n *int) { *n++ }).OrInsert(1)
+func (entry MaybeMapEntry[K, V]) OrInsert(val V) MapEntry[K, V] {
+	if entry.elem != nil {
+		return entry.OrDefault()
+	}
+	e := entry.OrDefault()
+	e.elem.Value = val
+	return e
+}
 
-	// Ensure the bucket slice exists
-	if bucket == nil {
-		bucket = m.newElemSlice(0, 1)
+// OrInsertWith returns the existing element, or inserts one with the value
+// returned by f. f is only called if the element does not exist.
+func (entry MaybeMapEntry[K, V]) OrInsertWith(f func() V) MapEntry[K, V] {
+	if entry.elem != nil {
+		return entry.OrDefault()
 	}
-	// Make room for the new element, reusing capacity when possible
-	if len(bucket)+1 <= cap(bucket) {
-		bucket = bucket[:len(bucket)+1]
-	} else {
-		if len(bucket) < 3 {
-			newBucket := m.newElemSlice(len(bucket)+1, 4)
-			copy(newBucket, bucket)
-			m.freeElemSlice(bucket)
-			bucket = newBucket
-		} else {
-			bucket = append(bucket, MapElement[K, V]{})
-		}
+	val := f()
+	e := entry.OrDefault()
+	e.elem.Value = val
+	return e
+}
+
+// AndModify calls f with a pointer to the value of the element if it exists,
+// and returns the entry for chaining with OrInsert, OrInsertWith or OrDefault.
+func (entry MaybeMapEntry[K, V]) AndModify(f func(*V)) MaybeMapEntry[K, V] {
+	if entry.elem != nil {
+		entry.mods.check(errModifiedSinceEntry)
+		f(&entry.elem.Value)
+	}
+	return entry
+}
+
+// Insert sets the value of the element, inserting it if it does not exist,
+// and returns the previous value, if any.
+// The entry must not be used after another write to the map.
+func (entry MaybeMapEntry[K, V]) Insert(val V) (V, bool) {
+	if entry.elem != nil {
+		entry.mods.check(errModifiedSinceEntry)
+		old := entry.elem.Value
+		entry.elem.Value = val
+		return old, true
+	}
+	entry.OrDefault().elem.Value = val
+	return *new(V), false
+}
+
+// Remove removes the element, if it exists, without hashing the key again,
+// and returns its value.
+// The entry must not be used after another write to the map.
+func (entry MaybeMapEntry[K, V]) Remove() (V, bool) {
+	if entry.elem == nil {
+		return *new(V), false
 	}
-	// Insert the new element at the end of the bucket (modulo length to
-	// avoid bounds checks)
-	pos := uint64(len(bucket)-1) % uint64(len(bucket))
-	bucket[pos].hash = hash
-	bucket[pos].Key = key
-
-	// Write the bucket back to the map's bucket array
-	m.buckets[hash%uint64(len(m.buckets))] = bucket
-	return MapEntry[K, V]{&bucket[pos]}
+	entry.mods.check(errModifiedSinceEntry)
+	removed, _ := entry.m.removeHash(entry.hash, entry.key)
+	return removed.Value, true
 }
@@ -0,0 +1,81 @@
+package genmap_test
+
+import (
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func TestEntry(t *testing.T) {
+	forEachBackend(t, testEntry)
+}
+
+func testEntry(t *testing.T, opts ...genmap.MapOption) {
+	hashes := 0
+	hasher := genmap.NewHasher[string]()
+	m := genmap.NewMapWithOptions[string, int](genmap.Equal[string], func(k string) uint64 {
+		hashes++
+		return hasher(k)
+	}, opts...)
+
+	// count the occurrences of each word
+	for _, w := range []string{"a", "b", "a", "c", "a"} {
+		m.Entry(w).AndModify(func(n *int) { *n++ }).OrInsert(1)
+	}
+	if hashes != 5 {
+		t.Errorf("expected 5 hash computations, got %d", hashes)
+	}
+	for k, want := range map[string]int{"a": 3, "b": 1, "c": 1} {
+		if got, _ := m.Get(k); got != want {
+			t.Errorf("expected %d for %s, got %d", want, k, got)
+		}
+	}
+
+	entry := m.Entry("a")
+	if entry.Key() != "a" {
+		t.Errorf("unexpected key %q", entry.Key())
+	}
+	if v, ok := entry.Value(); !ok || v != 3 {
+		t.Errorf("Value = %d, %v", v, ok)
+	}
+	*entry.ValuePtr() = 4
+	if old, ok := entry.Insert(5); !ok || old != 4 {
+		t.Errorf("Insert = %d, %v", old, ok)
+	}
+	if v, ok := entry.Remove(); !ok || v != 5 {
+		t.Errorf("Remove = %d, %v", v, ok)
+	}
+	if _, ok := m.Get("a"); ok || m.Len() != 2 {
+		t.Error("expected a to be removed")
+	}
+
+	entry = m.Entry("d")
+	if _, ok := entry.Value(); ok || entry.ValuePtr() != nil {
+		t.Error("expected no value")
+	}
+	if _, ok := entry.Remove(); ok {
+		t.Error("expected Remove to fail")
+	}
+	if _, ok := entry.Insert(1); ok {
+		t.Error("expected Insert to insert")
+	}
+	if v, _ := m.Get("d"); v != 1 {
+		t.Errorf("expected 1, got %d", v)
+	}
+
+	calls := 0
+	f := func() int { calls++; return 7 }
+	if e := m.Entry("e").OrInsertWith(f); e.Key() != "e" || e.Value() != 7 {
+		t.Errorf("unexpected entry %s: %d", e.Key(), e.Value())
+	}
+	if e := m.Entry("e").OrInsertWith(f); e.Value() != 7 || calls != 1 {
+		t.Error("expected f to be called once")
+	}
+	if e := m.Entry("e").OrInsert(8); e.Value() != 7 {
+		t.Error("expected OrInsert to keep the existing value")
+	}
+	*m.Entry("f").OrDefault().ValuePtr() += 2
+	if v, _ := m.Get("f"); v != 2 {
+		t.Errorf("expected 2, got %d", v)
+	}
+}
@@ -0,0 +1,217 @@
+package genmap_test
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+// Model-based testing: the fuzz targets decode a byte stream into a map
+// configuration and a sequence of operations, applied to a Map and to a
+// builtin map used as reference.
+
+// operations of the fuzzed sequences, each followed by a key byte
+const (
+	opPut = iota
+	opGet
+	opRemove
+	opUpsert
+	opEntry
+	opIteratorRemove
+	opClear
+	opCompare
+	opSnapshot
+	opCount
+)
+
+var fuzzMaxLoadFactors = []float64{0.5, 2, 8}
+
+// newFuzzMap returns a small map configured by b, so that operations hit
+// collisions, bucket growth and shrink.
+func newFuzzMap(b byte) *genmap.Map[int, int] {
+	hash := func(k int) uint64 {
+		return uint64(k) * 0x9e3779b97f4a7c15
+	}
+	if b&0x80 != 0 {
+		// few distinct hashes: long collision chains
+		hash = func(k int) uint64 {
+			return uint64(k % 3)
+		}
+	}
+	opts := []genmap.MapOption{
+		genmap.WithCapacity(1 + int(b&0x7)),
+		genmap.WithMaxLoadFactor(fuzzMaxLoadFactors[int(b>>3&0x3)%len(fuzzMaxLoadFactors)]),
+		genmap.WithGrowthPolicy(genmap.GrowthPolicy(int(b>>5&0x3) % 3)),
+	}
+	if b&0x40 != 0 && b&0x20 == 0 {
+		opts = append(opts, genmap.WithBackend(genmap.OpenAddressing))
+	}
+	return genmap.NewMapWithOptions[int, int](genmap.Equal[int], hash, opts...)
+}
+
+// runModel applies the operations encoded in data to a Map and a builtin map
+// and fails on the first difference.
+func runModel(t *testing.T, data []byte) {
+	if len(data) == 0 {
+		return
+	}
+	m := newFuzzMap(data[0])
+	ref := make(map[int]int)
+	type snapshot struct {
+		op  int
+		s   *genmap.MapSnapshot[int, int]
+		ref map[int]int
+	}
+	var snapshots []snapshot
+	data = data[1:]
+	for i := 0; i+1 < len(data); i += 2 {
+		op, key := int(data[i])%opCount, int(data[i+1]%64)
+		val := i
+		switch op {
+		case opPut:
+			m.Put(key, val)
+			ref[key] = val
+		case opGet:
+			got, ok := m.Get(key)
+			want, wantOk := ref[key]
+			if got != want || ok != wantOk {
+				t.Fatalf("op %d: Get(%d) = %d, %v, want %d, %v", i/2, key, got, ok, want, wantOk)
+			}
+		case opRemove:
+			got, ok := m.Remove(key)
+			want, wantOk := ref[key]
+			if ok != wantOk || (ok && (got.Key != key || got.Value != want)) {
+				t.Fatalf("op %d: Remove(%d) = %v, %v, want %d, %v", i/2, key, got, ok, want, wantOk)
+			}
+			delete(ref, key)
+		case opUpsert:
+			m.Upsert(key, func(elem *genmap.MapElement[int, int], exists bool) {
+				want, wantOk := ref[key]
+				if exists != wantOk || elem.Key != key || elem.Value != want {
+					t.Fatalf("op %d: Upsert(%d) called with %v, %v, want %d, %v", i/2, key, *elem, exists, want, wantOk)
+				}
+				elem.Value++
+			})
+			ref[key]++
+		case opEntry:
+			entry := m.Entry(key)
+			_, wantOk := ref[key]
+			if entry.Exists() != wantOk {
+				t.Fatalf("op %d: Entry(%d).Exists() = %v, want %v", i/2, key, entry.Exists(), wantOk)
+			}
+			entry.OrDefault().MutateWith(func(elem *genmap.MapElement[int, int]) {
+				if elem.Key != key || elem.Value != ref[key] {
+					t.Fatalf("op %d: Entry(%d).OrDefault() = %v, want %d", i/2, key, *elem, ref[key])
+				}
+				elem.Value += 2
+			})
+			ref[key] += 2
+		case opIteratorRemove:
+			// remove the keys congruent to key modulo 4
+			it := m.Iterator()
+			for it.Next() {
+				if cur := it.Cur().Key; cur%4 == key%4 {
+					if removed := it.Remove(); removed.Key != cur {
+						t.Fatalf("op %d: Remove removed %d, want %d", i/2, removed.Key, cur)
+					}
+				}
+			}
+			for k := range ref {
+				if k%4 == key%4 {
+					delete(ref, k)
+				}
+			}
+		case opClear:
+			m.Clear()
+			clear(ref)
+		case opCompare:
+			compareModel(t, i/2, m, ref)
+		case opSnapshot:
+			snapshots = append(snapshots, snapshot{i / 2, m.Snapshot(), maps.Clone(ref)})
+		}
+		if m.Len() != len(ref) {
+			t.Fatalf("op %d: Len() = %d, want %d", i/2, m.Len(), len(ref))
+		}
+	}
+	compareModel(t, len(data)/2, m, ref)
+	for _, s := range snapshots {
+		// the snapshots are not affected by the following operations
+		if got := maps.Collect(s.s.All()); !maps.Equal(got, s.ref) {
+			t.Fatalf("op %d: snapshot holds %v, want %v", s.op, got, s.ref)
+		}
+		for k, v := range s.ref {
+			if got, ok := s.s.Get(k); !ok || got != v {
+				t.Fatalf("op %d: snapshot Get(%d) = %d, %v, want %d", s.op, k, got, ok, v)
+			}
+		}
+	}
+}
+
+// compareModel checks that both the range iterator and the MapIterator yield
+// the elements of ref exactly once.
+func compareModel(t *testing.T, op int, m *genmap.Map[int, int], ref map[int]int) {
+	got := make(map[int]int)
+	for k, v := range m.All() {
+		if _, ok := got[k]; ok {
+			t.Fatalf("op %d: All yields %d twice", op, k)
+		}
+		got[k] = v
+	}
+	n := 0
+	it := m.Iterator()
+	for it.Next() {
+		if v, ok := ref[it.Cur().Key]; !ok || v != it.Cur().Value {
+			t.Fatalf("op %d: Iterator yields unexpected %v", op, *it.Cur())
+		}
+		n++
+	}
+	if n != len(ref) || len(got) != len(ref) {
+		t.Fatalf("op %d: iterators yield %d and %d elements, want %d", op, len(got), n, len(ref))
+	}
+	for k, v := range ref {
+		if got[k] != v {
+			t.Fatalf("op %d: All yields %d for %d, want %d", op, got[k], k, v)
+		}
+	}
+}
+
+func FuzzMapModel(f *testing.F) {
+	// fill then drain the map, for each configuration
+	for _, conf := range []byte{0x00, 0x08, 0x10, 0x20, 0x40, 0x80, 0x98, 0xd0, 0xc7} {
+		data := []byte{conf}
+		for k := range 48 {
+			data = append(data, opPut, byte(k))
+		}
+		for k := range 48 {
+			data = append(data, opRemove, byte(k), opGet, byte(k+1))
+		}
+		data = append(data, opCompare, 0)
+		f.Add(data)
+	}
+	f.Add([]byte{0x18, opPut, 1, opUpsert, 1, opEntry, 2, opIteratorRemove, 1, opCompare, 0, opClear, 0, opEntry, 3})
+	f.Add([]byte{0x00, opPut, 1, opPut, 2, opSnapshot, 0, opPut, 1, opRemove, 2, opSnapshot, 0, opPut, 3, opPut, 4, opPut, 5, opClear, 0})
+	f.Fuzz(runModel)
+}
+
+// TestMapModel runs the model with pseudo-random sequences, since the seed
+// corpus alone only covers simple scenarios.
+func TestMapModel(t *testing.T) {
+	for conf := range 256 {
+		data := []byte{byte(conf)}
+		x := uint32(conf + 1)
+		for range 400 {
+			// xorshift
+			x ^= x << 13
+			x ^= x >> 17
+			x ^= x << 5
+			op := byte(x % opCount)
+			if op == opClear && x%8 != 0 {
+				// keep the map populated most of the time
+				op = opPut
+			}
+			data = append(data, op, byte(x>>8))
+		}
+		runModel(t, data)
+	}
+}
@@ -0,0 +1,181 @@
+package genmaptest
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// HashReport describes the quality of a hash function over a set of keys.
+// It is returned by AnalyzeHash and AnalyzeHashes.
+type HashReport struct {
+	// Keys is the number of keys analyzed.
+	Keys int
+	// Buckets is the number of buckets the keys are distributed into, with
+	// hash % Buckets, as Map does.
+	Buckets int
+	// Collisions is the number of keys whose hash equals the hash of a
+	// previous key. With a 64-bit hash of distinct keys, it should be 0.
+	Collisions int
+	// Occupancy is the bucket occupancy histogram: Occupancy[n] is the number
+	// of buckets holding n keys.
+	Occupancy []int
+	// ChiSquare is the chi-square statistic of the bucket counts against a
+	// uniform distribution.
+	ChiSquare float64
+	// ChiSquareZ is ChiSquare normalized to a standard score: values above 3
+	// indicate a non uniform distribution.
+	ChiSquareZ float64
+	// BitBias is, for each bit of the hashes, the fraction of hashes with the
+	// bit set minus 0.5. A good hash has no bias.
+	BitBias [64]float64
+	// Avalanche is, for each bit of the hashes, the fraction of consecutive
+	// keys whose hashes differ at this bit. A good hash has an avalanche of
+	// 0.5 on every bit, so that similar keys have unrelated hashes.
+	Avalanche [64]float64
+}
+
+// AnalyzeHash hashes the given keys and reports the quality of the hash
+// function, with the keys distributed into the given number of buckets, or
+// as many buckets as keys if it is 0 (the size of a Map created with
+// WithCapacity(len(keys))). The keys should be distinct. The avalanche is
+// measured between consecutive keys, which should be similar (e.g. differ by
+// one field) in varied ways.
+func AnalyzeHash[K any](hash func(k K) uint64, keys []K, buckets int) HashReport {
+	hashes := make([]uint64, len(keys))
+	for i, k := range keys {
+		hashes[i] = hash(k)
+	}
+	if buckets == 0 {
+		buckets = len(keys)
+	}
+	return AnalyzeHashes(hashes, buckets)
+}
+
+// AnalyzeHashes reports the quality of the given hashes, distributed into the
+// given number of buckets. See AnalyzeHash.
+func AnalyzeHashes(hashes []uint64, buckets int) HashReport {
+	if buckets < 1 {
+		buckets = 1
+	}
+	r := HashReport{Keys: len(hashes), Buckets: buckets}
+
+	seen := make(map[uint64]struct{}, len(hashes))
+	counts := make([]int, buckets)
+	var bitCounts, flipCounts [64]int
+	for i, h := range hashes {
+		if _, ok := seen[h]; ok {
+			r.Collisions++
+		}
+		seen[h] = struct{}{}
+		counts[h%uint64(buckets)]++
+		for b := 0; b < 64; b++ {
+			bitCounts[b] += int(h >> b & 1)
+			if i > 0 {
+				flipCounts[b] += int((h ^ hashes[i-1]) >> b & 1)
+			}
+		}
+	}
+
+	expected := float64(len(hashes)) / float64(buckets)
+	for _, n := range counts {
+		for len(r.Occupancy) <= n {
+			r.Occupancy = append(r.Occupancy, 0)
+		}
+		r.Occupancy[n]++
+		if expected > 0 {
+			d := float64(n) - expected
+			r.ChiSquare += d * d / expected
+		}
+	}
+	if df := float64(buckets - 1); df > 0 {
+		r.ChiSquareZ = (r.ChiSquare - df) / math.Sqrt(2*df)
+	}
+	for b := 0; b < 64; b++ {
+		if len(hashes) > 0 {
+			r.BitBias[b] = float64(bitCounts[b])/float64(len(hashes)) - 0.5
+		}
+		if len(hashes) > 1 {
+			r.Avalanche[b] = float64(flipCounts[b]) / float64(len(hashes)-1)
+		}
+	}
+	return r
+}
+
+// MaxBucket returns the number of keys of the largest bucket.
+func (r HashReport) MaxBucket() int {
+	return len(r.Occupancy) - 1
+}
+
+// MaxBitBias returns the largest absolute bias of a bit.
+func (r HashReport) MaxBitBias() float64 {
+	var m float64
+	for _, b := range r.BitBias {
+		m = max(m, math.Abs(b))
+	}
+	return m
+}
+
+// MaxAvalancheBias returns the largest deviation of the avalanche of a bit
+// from 0.5.
+func (r HashReport) MaxAvalancheBias() float64 {
+	var m float64
+	for _, a := range r.Avalanche {
+		m = max(m, math.Abs(a-0.5))
+	}
+	return m
+}
+
+// Issues returns a description of the defects of the hash function found by
+// the analysis, nil if none. The bit tests tolerate a deviation of 5 standard
+// deviations for the number of keys.
+func (r HashReport) Issues() []string {
+	var issues []string
+	if r.Collisions > 0 {
+		issues = append(issues, fmt.Sprintf("%d hash collisions", r.Collisions))
+	}
+	if r.ChiSquareZ > 3 {
+		issues = append(issues, fmt.Sprintf("non uniform bucket distribution (chi-square z-score %.1f, largest bucket %d keys)", r.ChiSquareZ, r.MaxBucket()))
+	}
+	if r.Keys > 0 {
+		if b := r.MaxBitBias(); b > 5*0.5/math.Sqrt(float64(r.Keys)) {
+			issues = append(issues, fmt.Sprintf("biased bits (max bias %.3f)", b))
+		}
+	}
+	if r.Keys > 1 {
+		if b := r.MaxAvalancheBias(); b > 5*0.5/math.Sqrt(float64(r.Keys-1)) {
+			issues = append(issues, fmt.Sprintf("weak avalanche (max bias %.3f)", b))
+		}
+	}
+	return issues
+}
+
+// String returns a human readable summary of the report.
+func (r HashReport) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "keys: %d, buckets: %d, collisions: %d\n", r.Keys, r.Buckets, r.Collisions)
+	fmt.Fprintf(&sb, "chi-square: %.1f (z-score %.2f)\n", r.ChiSquare, r.ChiSquareZ)
+	fmt.Fprintf(&sb, "max bit bias: %.4f, max avalanche bias: %.4f\n", r.MaxBitBias(), r.MaxAvalancheBias())
+	fmt.Fprintf(&sb, "bucket occupancy:\n")
+	largest := 0
+	for _, n := range r.Occupancy {
+		largest = max(largest, n)
+	}
+	for size, n := range r.Occupancy {
+		if n == 0 {
+			continue
+		}
+		bar := 0
+		if largest > 0 {
+			bar = (n*40 + largest - 1) / largest
+		}
+		fmt.Fprintf(&sb, "%6d keys: %8d buckets %s\n", size, n, strings.Repeat("#", bar))
+	}
+	if issues := r.Issues(); len(issues) > 0 {
+		fmt.Fprintf(&sb, "issues:\n")
+		for _, issue := range issues {
+			fmt.Fprintf(&sb, "  %s\n", issue)
+		}
+	}
+	return sb.String()
+}
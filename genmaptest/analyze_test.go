@@ -0,0 +1,81 @@
+package genmaptest_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/ronanh/genmap"
+	"github.com/ronanh/genmap/genmaptest"
+)
+
+func intKeys(n int) []int {
+	keys := make([]int, n)
+	for i := range keys {
+		keys[i] = i
+	}
+	return keys
+}
+
+func TestAnalyzeHash(t *testing.T) {
+	r := genmaptest.AnalyzeHash(genmap.NewHasher[int](), intKeys(10000), 0)
+	if r.Keys != 10000 || r.Buckets != 10000 {
+		t.Errorf("unexpected keys or buckets: %d, %d", r.Keys, r.Buckets)
+	}
+	if issues := r.Issues(); issues != nil {
+		t.Errorf("unexpected issues with a good hash:\n%s", r)
+	}
+	total := 0
+	for size, n := range r.Occupancy {
+		total += size * n
+	}
+	if total != r.Keys {
+		t.Errorf("occupancy holds %d keys, want %d", total, r.Keys)
+	}
+
+	// given number of buckets: 10 keys per bucket on average
+	r = genmaptest.AnalyzeHash(genmap.NewHasher[int](), intKeys(10000), 1000)
+	if r.Buckets != 1000 {
+		t.Errorf("expected 1000 buckets, got %d", r.Buckets)
+	}
+	if issues := r.Issues(); issues != nil {
+		t.Errorf("unexpected issues with a good hash:\n%s", r)
+	}
+	buckets := 0
+	for _, n := range r.Occupancy {
+		buckets += n
+	}
+	if buckets != 1000 || len(r.Occupancy) <= 10 {
+		t.Errorf("unexpected occupancy %v", r.Occupancy)
+	}
+}
+
+func TestAnalyzeHashPoor(t *testing.T) {
+	tests := []struct {
+		name  string
+		hash  func(k int) uint64
+		issue string
+	}{
+		{"few values", func(k int) uint64 { return uint64(k % 7) }, "hash collisions"},
+		{"identity", func(k int) uint64 { return uint64(k) }, "biased bits"},
+		{"even", func(k int) uint64 { return uint64(k) * 0x9E3779B97F4A7C16 }, "non uniform bucket distribution"},
+		{"low bits only", func(k int) uint64 { return uint64(k) * 0x9E3779B97F4A7C15 >> 32 }, "weak avalanche"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := genmaptest.AnalyzeHash(test.hash, intKeys(10000), 0)
+			for _, issue := range r.Issues() {
+				if strings.Contains(issue, test.issue) {
+					return
+				}
+			}
+			t.Errorf("%q issue not reported:\n%s", test.issue, r)
+		})
+	}
+}
+
+func TestAnalyzeHashesEmpty(t *testing.T) {
+	r := genmaptest.AnalyzeHashes(nil, 0)
+	if r.Buckets != 1 || r.Issues() != nil {
+		t.Errorf("unexpected report:\n%s", r)
+	}
+}
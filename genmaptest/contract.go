@@ -0,0 +1,162 @@
+// Package genmaptest provides helpers to test the hash and equality functions
+// given to genmap maps.
+//
+// A Map relies on the hash and equality functions of its keys being
+// consistent: if equal(a, b) is true, hash(a) must equal hash(b). Otherwise
+// the map silently misbehaves: Get misses existing keys and Put inserts
+// duplicates.
+//
+// Example:
+//
+//	func TestMyKeyContract(t *testing.T) {
+//	    genmaptest.CheckHashEqualContract(t, MyKeyEquals, NewMyKeyHasher(), func(r *rand.Rand) MyKey {
+//	        return MyKey{r.Intn(10), []string{strconv.Itoa(r.Intn(3))}}
+//	    })
+//	}
+package genmaptest
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+const (
+	// contractKeys is the number of keys generated by CheckHashEqualContract.
+	contractKeys = 1000
+	// contractPairs is the number of generated keys compared pairwise.
+	contractPairs = 200
+)
+
+// CheckHashEqualContract checks that the equality and hash functions of a key
+// type fulfill the contract expected by genmap maps, with keys returned by gen:
+//
+//   - reflexivity: equal(a, a)
+//   - symmetry: equal(a, b) == equal(b, a)
+//   - consistency: equal(a, b) implies hash(a) == hash(b)
+//   - determinism: equal and hash return the same result when called again
+//
+// gen should return a small range of keys, so that equal keys are generated
+// often. Each key is also generated twice from the same seed: if gen is
+// deterministic, both keys are distinct values that are expected to be equal.
+//
+// For each violated property, the smallest counterexample found (by its %v
+// representation) is reported with t.Errorf.
+func CheckHashEqualContract[K any](t testing.TB, equal func(a, b K) bool, hash func(k K) uint64, gen func(r *rand.Rand) K) {
+	t.Helper()
+	keys := make([]K, contractKeys)
+	c := newContractChecker(equal, hash)
+	for i := range keys {
+		keys[i] = gen(rand.New(rand.NewSource(int64(i))))
+		c.checkKey(keys[i])
+		c.checkPair(keys[i], gen(rand.New(rand.NewSource(int64(i)))))
+	}
+	for i, a := range keys[:min(contractPairs, len(keys))] {
+		for _, b := range keys[i+1 : min(contractPairs, len(keys))] {
+			c.checkPair(a, b)
+		}
+	}
+	c.report(t)
+}
+
+// CheckHashEqualKeys is like CheckHashEqualContract but checks the given keys
+// and all their pairs. It is meant to be called from fuzz targets:
+//
+//	func FuzzMyKey(f *testing.F) {
+//	    f.Fuzz(func(t *testing.T, a, b int, s string) {
+//	        genmaptest.CheckHashEqualKeys(t, MyKeyEquals, NewMyKeyHasher(), MyKey{a, []string{s}}, MyKey{b, []string{s}})
+//	    })
+//	}
+func CheckHashEqualKeys[K any](t testing.TB, equal func(a, b K) bool, hash func(k K) uint64, keys ...K) {
+	t.Helper()
+	c := newContractChecker(equal, hash)
+	for i, a := range keys {
+		c.checkKey(a)
+		for _, b := range keys[i+1:] {
+			c.checkPair(a, b)
+		}
+	}
+	c.report(t)
+}
+
+// violation is the smallest counterexample of a property.
+type violation struct {
+	count   int
+	keys    string // %+v representation of the keys of the example
+	example string
+}
+
+// contractChecker records the violations of the contract properties.
+type contractChecker[K any] struct {
+	equal      func(a, b K) bool
+	hash       func(k K) uint64
+	violations map[string]*violation
+	properties []string // in the order of their first violation
+}
+
+func newContractChecker[K any](equal func(a, b K) bool, hash func(k K) uint64) *contractChecker[K] {
+	return &contractChecker[K]{
+		equal:      equal,
+		hash:       hash,
+		violations: make(map[string]*violation),
+	}
+}
+
+func (c *contractChecker[K]) checkKey(a K) {
+	if !c.equal(a, a) {
+		c.violate("reflexivity", a, func() string {
+			return fmt.Sprintf("equal(a, a) is false with a = %+v", a)
+		})
+	}
+	if h1, h2 := c.hash(a), c.hash(a); h1 != h2 {
+		c.violate("hash determinism", a, func() string {
+			return fmt.Sprintf("hash(a) returned %#x then %#x with a = %+v", h1, h2, a)
+		})
+	}
+}
+
+func (c *contractChecker[K]) checkPair(a, b K) {
+	ab, ba := c.equal(a, b), c.equal(b, a)
+	if ab != ba {
+		c.violate("symmetry", [2]K{a, b}, func() string {
+			return fmt.Sprintf("equal(a, b) is %v but equal(b, a) is %v with a = %+v, b = %+v", ab, ba, a, b)
+		})
+	}
+	if again := c.equal(a, b); again != ab {
+		c.violate("equal determinism", [2]K{a, b}, func() string {
+			return fmt.Sprintf("equal(a, b) returned %v then %v with a = %+v, b = %+v", ab, again, a, b)
+		})
+	}
+	if ab {
+		if ha, hb := c.hash(a), c.hash(b); ha != hb {
+			c.violate("hash/equal consistency", [2]K{a, b}, func() string {
+				return fmt.Sprintf("equal(a, b) is true but hash(a) = %#x, hash(b) = %#x with a = %+v, b = %+v", ha, hb, a, b)
+			})
+		}
+	}
+}
+
+// violate records a violation of the property with the given keys, keeping
+// the example with the shortest keys representation.
+func (c *contractChecker[K]) violate(property string, keys any, example func() string) {
+	v, ok := c.violations[property]
+	if !ok {
+		v = &violation{}
+		c.violations[property] = v
+		c.properties = append(c.properties, property)
+	}
+	v.count++
+	s := fmt.Sprintf("%+v", keys)
+	if v.count == 1 || len(s) < len(v.keys) || (len(s) == len(v.keys) && s < v.keys) {
+		v.keys = s
+		v.example = example()
+	}
+}
+
+func (c *contractChecker[K]) report(t testing.TB) {
+	t.Helper()
+	for _, property := range c.properties {
+		v := c.violations[property]
+		t.Errorf("%s violated %d times, smallest counterexample: %s", property, v.count, v.example)
+	}
+}
@@ -0,0 +1,150 @@
+package genmaptest_test
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/ronanh/genmap"
+	"github.com/ronanh/genmap/genmaptest"
+)
+
+// recorder records the errors reported by the checks.
+type recorder struct {
+	testing.TB
+	errors []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.errors = append(r.errors, fmt.Sprintf(format, args...))
+}
+
+type key struct {
+	id   int
+	tags []string
+}
+
+func genKey(r *rand.Rand) key {
+	k := key{id: r.Intn(5)}
+	for range r.Intn(3) {
+		k.tags = append(k.tags, strconv.Itoa(r.Intn(3)))
+	}
+	return k
+}
+
+func equalKey(a, b key) bool {
+	if a.id != b.id || len(a.tags) != len(b.tags) {
+		return false
+	}
+	for i := range a.tags {
+		if a.tags[i] != b.tags[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func hashKey() func(k key) uint64 {
+	intHasher := genmap.NewHasher[int]()
+	stringHasher := genmap.NewHasher[string]()
+	return func(k key) uint64 {
+		h := genmap.CombineHash(genmap.HashSeed, intHasher(k.id))
+		for _, tag := range k.tags {
+			h = genmap.CombineHash(h, stringHasher(tag))
+		}
+		return h
+	}
+}
+
+func TestCheckHashEqualContract(t *testing.T) {
+	genmaptest.CheckHashEqualContract(t, equalKey, hashKey(), genKey)
+	genmaptest.CheckHashEqualContract(t, genmap.NewAutoEqual[key](), genmap.NewAutoHasher[key](), genKey)
+}
+
+func TestCheckHashEqualContractViolations(t *testing.T) {
+	tests := []struct {
+		name     string
+		equal    func(a, b key) bool
+		hash     func(k key) uint64
+		property string
+		example  string
+	}{
+		{
+			name: "hash ignores equality",
+			// tags are ignored by equal but not by hash
+			equal:    func(a, b key) bool { return a.id == b.id },
+			hash:     hashKey(),
+			property: "hash/equal consistency",
+			example:  "a = {id:0 tags:[0]}, b = {id:0 tags:[]}",
+		},
+		{
+			name:     "not reflexive",
+			equal:    func(a, b key) bool { return equalKey(a, b) && a.id != 3 },
+			hash:     hashKey(),
+			property: "reflexivity",
+			example:  "a = {id:3 tags:[]}",
+		},
+		{
+			name:     "not symmetric",
+			equal:    func(a, b key) bool { return a.id <= b.id },
+			hash:     func(k key) uint64 { return 0 },
+			property: "symmetry",
+			example:  "a = {id:0 tags:[]}, b = {id:1 tags:[]}",
+		},
+		{
+			name:  "hash not deterministic",
+			equal: equalKey,
+			hash: func() func(k key) uint64 {
+				var n uint64
+				return func(k key) uint64 {
+					n++
+					return n
+				}
+			}(),
+			property: "hash determinism",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := &recorder{TB: t}
+			genmaptest.CheckHashEqualContract(r, test.equal, test.hash, genKey)
+			var found bool
+			for _, err := range r.errors {
+				if strings.HasPrefix(err, test.property+" violated") {
+					found = true
+					if !strings.HasSuffix(err, test.example) {
+						t.Errorf("unexpected counterexample: %s", err)
+					}
+				}
+			}
+			if !found {
+				t.Errorf("%s violation not reported, got %q", test.property, r.errors)
+			}
+		})
+	}
+}
+
+func TestCheckHashEqualKeys(t *testing.T) {
+	r := &recorder{TB: t}
+	genmaptest.CheckHashEqualKeys(r, equalKey, hashKey(), key{1, nil}, key{1, []string{}}, key{2, []string{"a"}})
+	if len(r.errors) != 0 {
+		t.Errorf("unexpected errors: %q", r.errors)
+	}
+	r = &recorder{TB: t}
+	genmaptest.CheckHashEqualKeys(r, genmap.NewAutoEqual[[]int](), genmap.NewAutoHasher[[]int](), nil, []int{}, []int{1})
+	if len(r.errors) != 0 {
+		t.Errorf("unexpected errors: %q", r.errors)
+	}
+}
+
+func FuzzHashEqualContract(f *testing.F) {
+	f.Add(1, "a", 1, "a")
+	equal, hash := genmap.NewAutoEqual[key](), genmap.NewAutoHasher[key]()
+	f.Fuzz(func(t *testing.T, id1 int, tag1 string, id2 int, tag2 string) {
+		genmaptest.CheckHashEqualKeys(t, equal, hash, key{id1, []string{tag1}}, key{id2, []string{tag2}}, key{id1, nil})
+	})
+}
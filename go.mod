@@ -1,5 +1,5 @@
 module github.com/ronanh/genmap
 
-go 1.19
+go 1.23
 
 require github.com/dolthub/maphash v0.1.0
@@ -0,0 +1,95 @@
+package genmap
+
+import (
+	"bytes"
+	"encoding/gob"
+	"errors"
+)
+
+// gobHeader precedes the elements in the encoding of MarshalBinary, so that
+// a map allocated by the decoder can be configured like the encoded one.
+type gobHeader struct {
+	Len           int
+	Backend       Backend
+	MaxLoadFactor float64
+	GrowthPolicy  GrowthPolicy
+	JSONObject    bool
+}
+
+// MarshalBinary implements encoding.BinaryMarshaler.
+// The keys and values are encoded with encoding/gob, along with the
+// configuration of the map. See WriteBinary for a more compact encoding.
+func (m *Map[K, V]) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	enc := gob.NewEncoder(&buf)
+	var h gobHeader
+	if m != nil {
+		h = gobHeader{m.len, Chaining, m.maxLoadFactor, m.growthPolicy, m.jsonObject}
+		if m.swiss != nil {
+			h.Backend = OpenAddressing
+		}
+	}
+	if err := enc.Encode(h); err != nil {
+		return nil, err
+	}
+	for elem := range m.elements(false) {
+		if err := enc.Encode(keyValue[K, V]{elem.Key, elem.Value}); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler.
+// The decoded elements are added to the map, replacing the values of existing
+// keys. If the map was not created with NewMap or NewMapWithOptions, for
+// instance when it is allocated by encoding/gob for a *Map field or value, it
+// is initialized with the configuration of the encoded map and with the
+// functions returned by NewAutoEqual and NewAutoHasher.
+func (m *Map[K, V]) UnmarshalBinary(data []byte) error {
+	if m == nil {
+		return errors.New("genmap: UnmarshalBinary on a nil map")
+	}
+	dec := gob.NewDecoder(bytes.NewReader(data))
+	var h gobHeader
+	if err := dec.Decode(&h); err != nil {
+		return err
+	}
+	if h.Len < 0 || h.Backend < Chaining || h.Backend > OpenAddressing ||
+		h.GrowthPolicy < GrowAndShrink || h.GrowthPolicy > FixedSize {
+		return errors.New("genmap: invalid encoded map header")
+	}
+	if m.hash == nil {
+		o := mapOptions{
+			// each element takes at least one byte
+			capacity:      min(h.Len, len(data)),
+			maxLoadFactor: defaultMaxLoadFactor,
+			growthPolicy:  h.GrowthPolicy,
+			backend:       h.Backend,
+			jsonObject:    h.JSONObject,
+		}
+		if h.MaxLoadFactor > 0 {
+			o.maxLoadFactor = h.MaxLoadFactor
+		}
+		*m = *newMap[K, V](NewAutoEqual[K](), NewAutoHasher[K](), o)
+	}
+	for range h.Len {
+		// gob omits zero fields: the pair must be zeroed before each element
+		var p keyValue[K, V]
+		if err := dec.Decode(&p); err != nil {
+			return err
+		}
+		m.Put(p.Key, p.Value)
+	}
+	return nil
+}
+
+// GobEncode implements gob.GobEncoder, with the encoding of MarshalBinary.
+func (m *Map[K, V]) GobEncode() ([]byte, error) {
+	return m.MarshalBinary()
+}
+
+// GobDecode implements gob.GobDecoder. See UnmarshalBinary.
+func (m *Map[K, V]) GobDecode(data []byte) error {
+	return m.UnmarshalBinary(data)
+}
@@ -0,0 +1,110 @@
+package genmap_test
+
+import (
+	"bytes"
+	"encoding/gob"
+	"maps"
+	"slices"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func TestMapGob(t *testing.T) {
+	forEachBackend(t, testMapGob)
+}
+
+func testMapGob(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[[]int, string](slices.Equal[[]int], genmap.NewAutoHasher[[]int](), opts...)
+	m.Put([]int{1, 2}, "a")
+	m.Put([]int{3}, "")
+	m.Put(nil, "c")
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(m); err != nil {
+		t.Fatal(err)
+	}
+
+	got := genmap.NewMapWithOptions[[]int, string](slices.Equal[[]int], genmap.NewAutoHasher[[]int](), opts...)
+	got.Put([]int{4}, "d")
+	if err := gob.NewDecoder(&buf).Decode(got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Len() != 4 {
+		t.Errorf("expected 4 elements, got %d", got.Len())
+	}
+	for _, tc := range []struct {
+		key  []int
+		want string
+	}{{[]int{1, 2}, "a"}, {[]int{3}, ""}, {nil, "c"}, {[]int{4}, "d"}} {
+		if v, ok := got.Get(tc.key); !ok || v != tc.want {
+			t.Errorf("expected %q for %v, got %q, %v", tc.want, tc.key, v, ok)
+		}
+	}
+}
+
+func TestMapGobNested(t *testing.T) {
+	type doc struct {
+		Name   string
+		Groups *genmap.Map[string, *genmap.Map[int, int]]
+		Empty  *genmap.Map[string, int]
+	}
+	d := doc{Name: "d", Groups: genmap.NewMapWithOptions[string, *genmap.Map[int, int]](
+		genmap.Equal[string], genmap.NewHasher[string](), genmap.WithBackend(genmap.OpenAddressing), genmap.WithJSONObject())}
+	for g := range 3 {
+		inner := genmap.NewMap[int, int](genmap.Equal[int], genmap.NewHasher[int](), 16)
+		for i := range g * 10 {
+			inner.Put(i, i*g)
+		}
+		d.Groups.Put(string(rune('a'+g)), inner)
+	}
+	d.Groups.Put("nil", nil)
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(d); err != nil {
+		t.Fatal(err)
+	}
+	var got doc
+	if err := gob.NewDecoder(&buf).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != "d" || got.Empty != nil || got.Groups.Len() != 4 {
+		t.Fatalf("unexpected decoded document %+v", got)
+	}
+	if inner, ok := got.Groups.Get("nil"); !ok || inner != nil {
+		t.Errorf("expected a nil map for nil, got %v, %v", inner, ok)
+	}
+	for g := range 3 {
+		inner, _ := got.Groups.Get(string(rune('a' + g)))
+		want, _ := d.Groups.Get(string(rune('a' + g)))
+		if !maps.Equal(maps.Collect(inner.All()), maps.Collect(want.All())) {
+			t.Errorf("unexpected map for group %d: %v", g, maps.Collect(inner.All()))
+		}
+	}
+	// the configuration of the encoded map is kept
+	if stats := got.Groups.Stats(); stats.Buckets == 0 || stats.OldBuckets != 0 {
+		t.Errorf("unexpected stats %+v", stats)
+	}
+	if data, err := got.Groups.MarshalJSON(); err != nil || data[0] != '{' {
+		t.Errorf("expected object JSON encoding, got %s, %v", data, err)
+	}
+}
+
+func TestMapMarshalBinary(t *testing.T) {
+	m := genmap.NewMap[string, float64](genmap.Equal[string], genmap.NewHasher[string](), 16)
+	m.Put("pi", 3.14)
+	m.Put("e", 2.72)
+	data, err := m.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got genmap.Map[string, float64]
+	if err := got.UnmarshalBinary(data); err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(maps.Collect(got.All()), map[string]float64{"pi": 3.14, "e": 2.72}) {
+		t.Errorf("unexpected decoded map %v", maps.Collect(got.All()))
+	}
+	if err := got.UnmarshalBinary(data[:len(data)-1]); err == nil {
+		t.Error("expected an error for truncated data")
+	}
+}
@@ -0,0 +1,141 @@
+package genmap
+
+import (
+	"bytes"
+	"encoding"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// keyValue is the encoding of an element of a Map, in JSON array form and
+// with gob.
+type keyValue[K any, V any] struct {
+	Key   K `json:"key"`
+	Value V `json:"value"`
+}
+
+// MarshalJSON implements json.Marshaler.
+// The map is encoded as an array of {"key": ..., "value": ...} objects, or as
+// an object if it was created with the WithJSONObject option.
+func (m *Map[K, V]) MarshalJSON() ([]byte, error) {
+	if m == nil {
+		return []byte("null"), nil
+	}
+	var buf bytes.Buffer
+	start, end := byte('['), byte(']')
+	if m.jsonObject {
+		start, end = '{', '}'
+	}
+	buf.WriteByte(start)
+	first := true
+	for k, v := range m.All() {
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		if !m.jsonObject {
+			b, err := json.Marshal(keyValue[K, V]{k, v})
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(b)
+			continue
+		}
+		s, err := marshalJSONKey(k)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(s)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+		buf.WriteByte(':')
+		if b, err = json.Marshal(v); err != nil {
+			return nil, err
+		}
+		buf.Write(b)
+	}
+	buf.WriteByte(end)
+	return buf.Bytes(), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+// It accepts both the array and the object forms written by MarshalJSON, and
+// adds the decoded elements to the map, replacing the values of existing keys.
+// Since the equality and hash functions cannot be decoded, the map must have
+// been created with NewMap or NewMapWithOptions beforehand; a Map allocated by
+// encoding/json, for instance for a *Map field, cannot be decoded into.
+func (m *Map[K, V]) UnmarshalJSON(data []byte) error {
+	if m == nil || m.hash == nil {
+		return errors.New("genmap: UnmarshalJSON on a map not created with NewMap")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case nil:
+		// null leaves the map unchanged, like for builtin maps
+		return nil
+	case json.Delim('['):
+		for dec.More() {
+			var p keyValue[K, V]
+			if err := dec.Decode(&p); err != nil {
+				return err
+			}
+			m.Put(p.Key, p.Value)
+		}
+	case json.Delim('{'):
+		for dec.More() {
+			tok, err := dec.Token()
+			if err != nil {
+				return err
+			}
+			k, err := unmarshalJSONKey[K](tok.(string))
+			if err != nil {
+				return err
+			}
+			var v V
+			if err := dec.Decode(&v); err != nil {
+				return err
+			}
+			m.Put(k, v)
+		}
+	default:
+		return fmt.Errorf("genmap: cannot unmarshal %v into a map", tok)
+	}
+	// closing delimiter
+	_, err = dec.Token()
+	return err
+}
+
+// marshalJSONKey returns the JSON object key of k, like encoding/json does for
+// the keys of builtin maps.
+func marshalJSONKey[K any](k K) (string, error) {
+	if rv := reflect.ValueOf(&k).Elem(); rv.Kind() == reflect.String {
+		return rv.String(), nil
+	}
+	if tm, ok := any(k).(encoding.TextMarshaler); ok {
+		b, err := tm.MarshalText()
+		return string(b), err
+	}
+	return "", fmt.Errorf("genmap: unsupported JSON object key type %v", reflect.TypeFor[K]())
+}
+
+// unmarshalJSONKey decodes the JSON object key s.
+func unmarshalJSONKey[K any](s string) (K, error) {
+	var k K
+	if tu, ok := any(&k).(encoding.TextUnmarshaler); ok {
+		err := tu.UnmarshalText([]byte(s))
+		return k, err
+	}
+	if rv := reflect.ValueOf(&k).Elem(); rv.Kind() == reflect.String {
+		rv.SetString(s)
+		return k, nil
+	}
+	return k, fmt.Errorf("genmap: unsupported JSON object key type %v", reflect.TypeFor[K]())
+}
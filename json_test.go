@@ -0,0 +1,126 @@
+package genmap_test
+
+import (
+	"encoding/json"
+	"maps"
+	"net/netip"
+	"slices"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func TestMapJSON(t *testing.T) {
+	forEachBackend(t, testMapJSON)
+}
+
+func testMapJSON(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[[]int, string](slices.Equal[[]int], genmap.NewAutoHasher[[]int](), opts...)
+	m.Put([]int{1, 2}, "a")
+	m.Put([]int{3}, "b")
+	m.Put(nil, "c")
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pairs []struct {
+		Key   []int
+		Value string
+	}
+	if err := json.Unmarshal(data, &pairs); err != nil || len(pairs) != 3 {
+		t.Fatalf("expected an array of 3 pairs, got %s, %v", data, err)
+	}
+
+	got := genmap.NewMapWithOptions[[]int, string](slices.Equal[[]int], genmap.NewAutoHasher[[]int](), opts...)
+	got.Put([]int{3}, "x")
+	got.Put([]int{4}, "d")
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	// the decoded elements are added to the existing ones
+	if got.Len() != 4 {
+		t.Errorf("expected 4 elements, got %d", got.Len())
+	}
+	for _, tc := range []struct {
+		key  []int
+		want string
+	}{{[]int{1, 2}, "a"}, {[]int{3}, "b"}, {nil, "c"}, {[]int{4}, "d"}} {
+		if v, _ := got.Get(tc.key); v != tc.want {
+			t.Errorf("expected %q for %v, got %q", tc.want, tc.key, v)
+		}
+	}
+
+	if err := json.Unmarshal([]byte("null"), got); err != nil || got.Len() != 4 {
+		t.Errorf("expected null to leave the map unchanged, got %d elements, %v", got.Len(), err)
+	}
+	if err := json.Unmarshal([]byte(`"x"`), got); err == nil {
+		t.Error("expected an error for a string")
+	}
+}
+
+type userID string
+
+func TestMapJSONObject(t *testing.T) {
+	m := genmap.NewMapWithOptions[userID, int](genmap.Equal[userID], genmap.NewHasher[userID](), genmap.WithJSONObject())
+	m.Put("alice", 1)
+	m.Put("bob", 2)
+	data, err := json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var obj map[string]int
+	if err := json.Unmarshal(data, &obj); err != nil || !maps.Equal(obj, map[string]int{"alice": 1, "bob": 2}) {
+		t.Fatalf("expected an object, got %s, %v", data, err)
+	}
+	got := genmap.NewMap[userID, int](genmap.Equal[userID], genmap.NewHasher[userID]())
+	if err := json.Unmarshal(data, got); err != nil {
+		t.Fatal(err)
+	}
+	if !maps.Equal(maps.Collect(got.All()), map[userID]int{"alice": 1, "bob": 2}) {
+		t.Errorf("unexpected decoded map %v", maps.Collect(got.All()))
+	}
+
+	// text marshalers
+	addrs := genmap.NewMapWithOptions[netip.Addr, bool](genmap.Equal[netip.Addr], genmap.NewHasher[netip.Addr](), genmap.WithJSONObject())
+	addrs.Put(netip.MustParseAddr("10.0.0.1"), true)
+	if data, err = json.Marshal(addrs); err != nil || string(data) != `{"10.0.0.1":true}` {
+		t.Fatalf("unexpected encoding %s, %v", data, err)
+	}
+	addrs.Clear()
+	if err := json.Unmarshal(data, addrs); err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := addrs.Get(netip.MustParseAddr("10.0.0.1")); !v {
+		t.Error("expected decoded address")
+	}
+
+	ints := genmap.NewMapWithOptions[int, int](genmap.Equal[int], genmap.NewHasher[int](), genmap.WithJSONObject())
+	ints.Put(1, 1)
+	if _, err := json.Marshal(ints); err == nil {
+		t.Error("expected an error for int keys in object form")
+	}
+}
+
+func TestMapJSONNested(t *testing.T) {
+	type doc struct {
+		Groups *genmap.Map[string, *genmap.Map[string, int]]
+	}
+	inner := genmap.NewMapWithOptions[string, int](genmap.Equal[string], genmap.NewHasher[string](), genmap.WithJSONObject())
+	inner.Put("x", 1)
+	d := doc{genmap.NewMapWithOptions[string, *genmap.Map[string, int]](genmap.Equal[string], genmap.NewHasher[string](), genmap.WithJSONObject())}
+	d.Groups.Put("g", inner)
+	data, err := json.Marshal(d)
+	if err != nil || string(data) != `{"Groups":{"g":{"x":1}}}` {
+		t.Fatalf("unexpected encoding %s, %v", data, err)
+	}
+
+	// maps allocated by encoding/json have no hash function
+	var got doc
+	if err := json.Unmarshal(data, &got); err == nil {
+		t.Error("expected an error decoding into a nil map")
+	}
+	got.Groups = genmap.NewMap[string, *genmap.Map[string, int]](genmap.Equal[string], genmap.NewHasher[string]())
+	if err := json.Unmarshal(data, &got); err == nil {
+		t.Error("expected an error decoding nested maps")
+	}
+}
@@ -1,7 +1,20 @@
 package genmap
 
+import "math"
+
 const (
 	maxFreeSlices = 128
+
+	// defaultMaxLoadFactor is the average number of elements per bucket above
+	// which the bucket array is grown.
+	defaultMaxLoadFactor = 2.0
+	// evacuateSteps is the number of old buckets migrated on each write while
+	// a resize is in progress, on top of the bucket being written to.
+	evacuateSteps = 2
+
+	errModifiedDuringIteration = "map modified during iteration"
+	errModifiedSinceEntry      = "map modified since the entry was created"
+	errKeyModified             = "map key modified in place"
 )
 
 // MapElement is a generic key-value pair used in the Map[K, V] implementation.
@@ -12,36 +25,56 @@ type MapElement[K any, V any] struct {
 }
 
 // Map is a generic hash map implementation that allows any type for keys.
-// Map instance should be instantiated using the NewMap function.
+// Map instance should be instantiated using the NewMap or NewMapWithOptions functions.
 type Map[K, V any] struct {
 	equal       func(k1, k2 K) bool
 	hash        func(k K) uint64
 	buckets     [][]MapElement[K, V]
 	len         int
+	mods        modCount // only maintained with the genmapdebug build tag
 	allocBuffer []MapElement[K, V]
 	freeSlices  [][]MapElement[K, V]
+
+	// incremental resizing: while oldBuckets is not nil, elements are
+	// migrated from oldBuckets to buckets a few buckets at a time.
+	// A non-empty old bucket holds all the elements for its hash positions.
+	oldBuckets    [][]MapElement[K, V]
+	evacuatePos   int
+	maxLoadFactor float64
+	growthPolicy  GrowthPolicy
+	minBuckets    int
+	growAt        int
+	shrinkAt      int
+
+	// open addressing backend, buckets are not used when set
+	swiss *swissTable[K, V]
+
+	// number of range-over-func iterations in progress
+	iterating int
+
+	// buckets shared with snapshots, nil if none
+	cow *mapCOW
+
+	// JSON encoding as an object (WithJSONObject)
+	jsonObject bool
 }
 
 // NewMap returns a new instance of Map[K, V] with the given equality and hash functions.
 // The optional bucketSizeOpt parameter specifies the size of each bucket in the map.
 // If not provided, a default bucket size (64k) is used.
-// Special care should be taken when choosing a bucket size as it can have a significant impact on performance.
-// For good performance, the bucket size should be close to the expected number of elements in the map.
+// The number of buckets grows (and shrinks back, never below the initial bucket size) with the
+// number of elements. Resizing is incremental: elements are migrated over subsequent writes.
+// Choosing a bucket size close to the expected number of elements avoids resizing altogether.
+// See NewMapWithOptions to size the map from its expected number of elements instead.
 func NewMap[K any, V any](equal func(k1, k2 K) bool, hash func(k K) uint64, bucketSizeOpt ...int) *Map[K, V] {
 	if len(bucketSizeOpt) > 1 {
 		panic("too many arguments")
 	}
-	bucketsSize := 64 << 10
+	o := mapOptions{maxLoadFactor: defaultMaxLoadFactor}
 	if len(bucketSizeOpt) == 1 {
-		bucketsSize = bucketSizeOpt[0]
+		o.bucketsSize = bucketSizeOpt[0]
 	}
-
-	bucket := &Map[K, V]{
-		equal:   equal,
-		hash:    hash,
-		buckets: make([][]MapElement[K, V], bucketsSize),
-	}
-	return bucket
+	return newMap[K, V](equal, hash, o)
 }
 
 // returns the number of elements in the map.
@@ -54,9 +87,19 @@ func (m *Map[K, V]) Len() int {
 
 // Clear removes all elements from the map.
 func (m *Map[K, V]) Clear() {
+	m.mods.inc()
+	if m.swiss != nil {
+		m.swiss.clear()
+		m.len = 0
+		return
+	}
+	m.ownBuckets()
 	for i := range m.buckets {
 		m.buckets[i] = nil
 	}
+	m.cow = nil
+	m.oldBuckets = nil
+	m.evacuatePos = 0
 	m.len = 0
 }
 
@@ -65,9 +108,20 @@ func (m *Map[K, V]) Get(key K) (V, bool) {
 	if m == nil {
 		return *new(V), false
 	}
-	hash := m.hash(key)
-	bucketID := hash % uint64(len(m.buckets))
-	bucket := m.buckets[bucketID]
+	return m.get(m.hash(key), key)
+}
+
+// get returns the value associated with key, whose hash is hash.
+// It does not modify the map, so it may be called while holding a read lock.
+func (m *Map[K, V]) get(hash uint64, key K) (V, bool) {
+	if m.swiss != nil {
+		if slot := m.swiss.find(hash, key); slot >= 0 {
+			return m.swiss.slots[slot].Value, true
+		}
+		return *new(V), false
+	}
+	buckets, bucketID := m.locate(hash)
+	bucket := buckets[bucketID]
 	if len(bucket) == 0 {
 		return *new(V), false
 	}
@@ -90,48 +144,33 @@ func (m *Map[K, V]) Get(key K) (V, bool) {
 // Put inserts the given key-value pair into the map.
 func (m *Map[K, V]) Put(key K, val V) {
 	hash := m.hash(key)
-	bucket := m.buckets[hash%uint64(len(m.buckets))]
+	if m.swiss != nil {
+		if slot := m.swiss.find(hash, key); slot >= 0 {
+			m.swiss.slots[slot].Value = val
+			return
+		}
+		m.insert(hash, key).Value = val
+		return
+	}
+	m.ownBuckets()
+	buckets, bucketID := m.locate(hash)
+	bucket := buckets[bucketID]
 	if len(bucket) > 0 {
 		if bucket[0].hash == hash && m.equal(bucket[0].Key, key) {
-			bucket[0].Value = val
+			m.ownBucket(buckets, bucketID)[0].Value = val
 			return
 		}
 		if len(bucket) > 1 {
 			// slow path
 			for pos := 1; pos < len(bucket); pos++ {
 				if bucket[pos].hash == hash && m.equal(bucket[pos].Key, key) {
-					bucket[pos].Value = val
+					m.ownBucket(buckets, bucketID)[pos].Value = val
 					return
 				}
 			}
 		}
 	}
-	m.len++
-	if bucket == nil {
-		bucket = m.newElemSlice(0, 1)
-	}
-	if len(bucket)+1 > cap(bucket) {
-		if len(bucket) < 3 {
-			newBucket := m.newElemSlice(len(bucket)+1, 4)
-			copy(newBucket, bucket)
-			m.freeElemSlice(bucket)
-			bucket = newBucket
-		} else {
-			bucket = append(bucket, MapElement[K, V]{
-				Key:   key,
-				Value: val,
-				hash:  hash,
-			})
-		}
-	} else {
-		bucket = bucket[:len(bucket)+1]
-		bucket[len(bucket)-1] = MapElement[K, V]{
-			Key:   key,
-			Value: val,
-			hash:  hash,
-		}
-	}
-	m.buckets[hash%uint64(len(m.buckets))] = bucket
+	m.insert(hash, key).Value = val
 }
 
 // Entry returns a MaybeMapEntry that provides optional access to the element
@@ -154,7 +193,8 @@ func (m *Map[K, V]) Put(key K, val V) {
 //
 //	if entry.Exists() {
 //	    // Read the value.
-//	    fmt.Println(entry.Get().Value) // Output: 42
+//	    v, _ := entry.Value()
+//	    fmt.Println(v) // Output: 42
 //
 //	    // Mutate the value in‑place.
 //	    entry.OrDefault().MutateWith(func(e *genmap.MapElement[string, int]) {
@@ -166,7 +206,7 @@ func (m *Map[K, V]) Put(key K, val V) {
 //	removed, ok := m.Remove("foo")
 //	fmt.Println(removed, ok) // Output: {foo 43 0} true
 func (m *Map[K, V]) Entry(key K) MaybeMapEntry[K, V] {
-	return makeOptionalEntry(m, key)
+	return makeOptionalEntry(m, m.hash(key), key)
 }
 
 // Upsert inserts or modifies the given entry into the map.
@@ -181,30 +221,61 @@ func (m *Map[K, V]) Upsert(key K, update func(elem *MapElement[K, V], exists boo
 
 // Remove removes the given key from the map and returns it.
 func (m *Map[K, V]) Remove(key K) (MapElement[K, V], bool) {
-	hash := m.hash(key)
-	bucketID := hash % uint64(len(m.buckets))
-	bucket := m.buckets[bucketID]
+	return m.removeHash(m.hash(key), key)
+}
+
+// removeHash removes the given key, whose hash is hash, from the map.
+func (m *Map[K, V]) removeHash(hash uint64, key K) (MapElement[K, V], bool) {
+	if m.swiss != nil {
+		slot := m.swiss.find(hash, key)
+		if slot < 0 {
+			return MapElement[K, V]{}, false
+		}
+		m.len--
+		m.mods.inc()
+		elem := m.swiss.removeAt(slot)
+		if m.growthPolicy == GrowAndShrink && m.iterating == 0 {
+			m.swiss.shrink(m.len)
+		}
+		return elem, true
+	}
+	m.ownBuckets()
+	buckets, bucketID := m.locate(hash)
+	bucket := buckets[bucketID]
 	if len(bucket) == 0 {
 		return MapElement[K, V]{}, false
 	}
-	if bucket[0].hash == hash && m.equal(bucket[0].Key, key) {
-		return m.remove(bucketID, uint64(0)), true
-	}
-	if len(bucket) > 1 {
+	pos := 0
+	if bucket[0].hash != hash || !m.equal(bucket[0].Key, key) {
 		// slow path
-		for pos := 1; pos < len(bucket); pos++ {
+		for pos = 1; pos < len(bucket); pos++ {
 			if bucket[pos].hash == hash && m.equal(bucket[pos].Key, key) {
-				return m.remove(bucketID, uint64(pos)), true
+				break
 			}
 		}
+		if pos == len(bucket) {
+			return MapElement[K, V]{}, false
+		}
+	}
+	elem := m.remove(buckets, bucketID, uint64(pos))
+	switch {
+	case m.iterating > 0:
+		// elements must not move during an iteration
+	case m.oldBuckets != nil:
+		m.evacuateNext()
+	case m.len < m.shrinkAt:
+		m.resize(len(m.buckets) / 2)
 	}
-	return MapElement[K, V]{}, false
+	return elem, true
 }
 
-func (m *Map[K, V]) remove(bucketID uint64, pos uint64) (elem MapElement[K, V]) {
+// remove removes the element at pos in the bucket bucketID of buckets, which
+// is either m.buckets or m.oldBuckets.
+func (m *Map[K, V]) remove(buckets [][]MapElement[K, V], bucketID uint64, pos uint64) (elem MapElement[K, V]) {
 	m.len--
-	bucket := m.buckets[bucketID%uint64(len(m.buckets))] // Eliminate bounds check
-	pos = pos % uint64(len(bucket))                      // Eliminate bounds check
+	m.mods.inc()
+	bucket := m.ownBucket(buckets, bucketID%uint64(len(buckets))) // Eliminate bounds check
+	pos = pos % uint64(len(bucket))                               // Eliminate bounds check
 	elem = bucket[pos]
 	copy(bucket[pos:], bucket[pos+1:])
 	// force clear the last element to avoid memory leak
@@ -213,21 +284,147 @@ func (m *Map[K, V]) remove(bucketID uint64, pos uint64) (elem MapElement[K, V])
 	if len(bucket) == 0 {
 		// free the bucket
 		m.freeElemSlice(bucket)
-		m.buckets[bucketID%uint64(len(m.buckets))] = nil
+		buckets[bucketID%uint64(len(buckets))] = nil
 		return
 	} else if len(bucket)+1 < cap(bucket)/3 {
 		// shrink the bucket
-		newBucket := make([]MapElement[K, V], cap(bucket)/2)
+		newBucket := make([]MapElement[K, V], len(bucket), cap(bucket)/2)
 		copy(newBucket, bucket)
 		bucket = newBucket
 	}
-	m.buckets[bucketID%uint64(len(m.buckets))] = bucket // Eliminate bounds check
+	buckets[bucketID%uint64(len(buckets))] = bucket // Eliminate bounds check
 	return
 }
 
+// locate returns the bucket array (m.buckets or m.oldBuckets) and the bucket
+// index holding the elements with the given hash.
+func (m *Map[K, V]) locate(hash uint64) ([][]MapElement[K, V], uint64) {
+	if m.oldBuckets != nil {
+		pos := hash % uint64(len(m.oldBuckets))
+		if len(m.oldBuckets[pos]) > 0 {
+			return m.oldBuckets, pos
+		}
+	}
+	return m.buckets, hash % uint64(len(m.buckets))
+}
+
+// insert adds a new element for key, which must not already be in the map,
+// and returns a pointer to it. The value of the new element is zero.
+func (m *Map[K, V]) insert(hash uint64, key K) *MapElement[K, V] {
+	m.mods.inc()
+	if m.swiss != nil {
+		m.len++
+		return m.swiss.insert(hash, key, m.len-1)
+	}
+	m.len++
+	m.ownBuckets()
+	if m.len > m.growAt {
+		m.resize(len(m.buckets) * 2)
+	}
+	if m.oldBuckets != nil {
+		// the old bucket of the key must be migrated before
+		// the new element is added to m.buckets
+		m.evacuate(hash % uint64(len(m.oldBuckets)))
+		m.evacuateNext()
+	}
+	bucketID := hash % uint64(len(m.buckets))
+	bucket := m.appendElem(m.ownBucket(m.buckets, bucketID))
+	// modulo length to avoid bounds checks
+	pos := uint64(len(bucket)-1) % uint64(len(bucket))
+	bucket[pos].hash = hash
+	bucket[pos].Key = key
+	m.buckets[bucketID] = bucket
+	return &bucket[pos]
+}
+
+// appendElem grows bucket by one zero element, reusing capacity when possible.
+func (m *Map[K, V]) appendElem(bucket []MapElement[K, V]) []MapElement[K, V] {
+	if bucket == nil {
+		bucket = m.newElemSlice(0, 1)
+	}
+	if len(bucket)+1 <= cap(bucket) {
+		return bucket[:len(bucket)+1]
+	}
+	if len(bucket) < 3 {
+		newBucket := m.newElemSlice(len(bucket)+1, 4)
+		copy(newBucket, bucket)
+		m.freeElemSlice(bucket)
+		return newBucket
+	}
+	return append(bucket, MapElement[K, V]{})
+}
+
+// resize starts migrating the elements to a new bucket array of the given size.
+// A resize already in progress is completed first.
+func (m *Map[K, V]) resize(size int) {
+	for m.oldBuckets != nil {
+		m.evacuate(uint64(m.evacuatePos))
+	}
+	m.oldBuckets = m.buckets
+	m.evacuatePos = 0
+	m.buckets = make([][]MapElement[K, V], size)
+	if m.cow != nil {
+		m.cow.oldShared, m.cow.shared = m.cow.shared, nil
+	}
+	m.setThresholds()
+}
+
+// setThresholds computes the number of elements above which the map grows
+// and below which it shrinks for the current bucket array.
+func (m *Map[K, V]) setThresholds() {
+	size := float64(len(m.buckets))
+	m.growAt = math.MaxInt
+	if m.growthPolicy != FixedSize && m.maxLoadFactor*size < math.MaxInt {
+		m.growAt = int(m.maxLoadFactor * size)
+	}
+	m.shrinkAt = 0
+	if m.growthPolicy == GrowAndShrink && len(m.buckets) > m.minBuckets {
+		// shrink well below the grow threshold to avoid oscillating
+		m.shrinkAt = int(m.maxLoadFactor * size / 8)
+	}
+}
+
+// evacuateNext migrates the next few old buckets.
+func (m *Map[K, V]) evacuateNext() {
+	for i := 0; i < evacuateSteps && m.oldBuckets != nil; i++ {
+		m.evacuate(uint64(m.evacuatePos))
+	}
+}
+
+// evacuate moves the elements of the old bucket oldPos to m.buckets.
+// The resize completes once every old bucket has been emptied.
+func (m *Map[K, V]) evacuate(oldPos uint64) {
+	old := m.oldBuckets[oldPos]
+	for i := range old {
+		bucketID := old[i].hash % uint64(len(m.buckets))
+		bucket := m.appendElem(m.ownBucket(m.buckets, bucketID))
+		bucket[len(bucket)-1] = old[i]
+		m.buckets[bucketID] = bucket
+	}
+	if old != nil {
+		if !m.isShared(m.oldBuckets, oldPos) {
+			m.freeElemSlice(old)
+		}
+		m.oldBuckets[oldPos] = nil
+	}
+	for m.evacuatePos < len(m.oldBuckets) && m.oldBuckets[m.evacuatePos] == nil {
+		m.evacuatePos++
+	}
+	if m.evacuatePos == len(m.oldBuckets) {
+		m.oldBuckets = nil
+		m.evacuatePos = 0
+		if m.cow != nil {
+			m.cow.oldShared = nil
+		}
+	}
+}
+
 // Iterator returns a new iterator over the map.
 func (m *Map[K, V]) Iterator() *MapIterator[K, V] {
-	return &MapIterator[K, V]{m: m}
+	if m == nil {
+		return &MapIterator[K, V]{}
+	}
+	return &MapIterator[K, V]{m: m, mods: m.mods.snapshot()}
 }
 
 func (m *Map[K, V]) newElemSlice(size, capacity int) []MapElement[K, V] {
@@ -259,26 +456,39 @@ func (m *Map[K, V]) freeElemSlice(slice []MapElement[K, V]) {
 }
 
 // MapIterator is an iterator over a map.
+// The map must not be modified while iterating, except through Remove.
+// With the genmapdebug build tag, Next and Cur panic if it was.
 type MapIterator[K any, V any] struct {
 	m      *Map[K, V]
+	mods   modSnapshot
 	mapPos uint64
 	pos    uint64
 	ready  bool
 }
 
 // Next advances the iterator and returns true if there is another element
+// With the genmapdebug build tag, Next panics if the key of the current
+// element was modified.
 func (it *MapIterator[K, V]) Next() bool {
 	if it.m == nil {
 		return false
 	}
+	it.mods.check(errModifiedDuringIteration)
+	if debug && it.ready {
+		elem := it.cur(false)
+		newKeyGuard(it.m.hash, elem.hash).check(elem.Key)
+	}
+	if it.m.swiss != nil {
+		return it.nextSlot()
+	}
 	if it.ready {
 		// ensure the cursor is moved
 		it.pos++
 	}
 	// ensure the cursor is at a valid position
 	// otherwise move to the next valid position
-	for it.mapPos < uint64(len(it.m.buckets)) {
-		if it.pos < uint64(len(it.m.buckets[it.mapPos])) {
+	for it.mapPos < uint64(len(it.m.oldBuckets)+len(it.m.buckets)) {
+		if buckets, bucketID := it.bucket(); it.pos < uint64(len(buckets[bucketID])) {
 			it.ready = true
 			return true
 		}
@@ -289,12 +499,47 @@ func (it *MapIterator[K, V]) Next() bool {
 	return false
 }
 
-// Cur returns the current element
+// Cur returns the current element. Its key must not be modified: use CurKey
+// and CurValue to only access its value.
 func (it *MapIterator[K, V]) Cur() *MapElement[K, V] {
-	if !it.ready || it.mapPos >= uint64(len(it.m.buckets)) || it.pos >= uint64(len(it.m.buckets[it.mapPos])) {
+	return it.cur(true)
+}
+
+// cur returns the current element. Unless writable, the element may be shared
+// with a snapshot and must not be modified.
+func (it *MapIterator[K, V]) cur(writable bool) *MapElement[K, V] {
+	it.mods.check(errModifiedDuringIteration)
+	if t := it.m.swiss; t != nil {
+		if !it.ready || it.mapPos >= uint64(len(t.slots)) || !t.isFull(int(it.mapPos)) {
+			panic("iterator position not set")
+		}
+		return &t.slots[it.mapPos]
+	}
+	if !it.ready || it.mapPos >= uint64(len(it.m.oldBuckets)+len(it.m.buckets)) {
 		panic("iterator position not set")
 	}
-	return &it.m.buckets[it.mapPos][it.pos]
+	if writable {
+		it.m.ownBuckets()
+	}
+	buckets, bucketID := it.bucket()
+	if it.pos >= uint64(len(buckets[bucketID])) {
+		panic("iterator position not set")
+	}
+	if writable {
+		return &it.m.ownBucket(buckets, bucketID)[it.pos]
+	}
+	return &buckets[bucketID][it.pos]
+}
+
+// CurKey returns the key of the current element.
+func (it *MapIterator[K, V]) CurKey() K {
+	return it.Cur().Key
+}
+
+// CurValue returns a pointer to the value of the current element, to modify
+// it in place. The pointer must not be used after the map is modified.
+func (it *MapIterator[K, V]) CurValue() *V {
+	return &it.Cur().Value
 }
 
 // Remove removes the current element from the map and returns it.
@@ -303,12 +548,54 @@ func (it *MapIterator[K, V]) Remove() MapElement[K, V] {
 	if !it.ready {
 		panic("iterator position not set")
 	}
+	it.mods.check(errModifiedDuringIteration)
 	it.ready = false
-	return it.m.remove(uint64(it.mapPos), it.pos)
+	var elem MapElement[K, V]
+	if it.m.swiss != nil {
+		// removing does not move the other elements
+		it.m.len--
+		it.m.mods.inc()
+		elem = it.m.swiss.removeAt(int(it.mapPos))
+	} else {
+		it.m.ownBuckets()
+		buckets, bucketID := it.bucket()
+		elem = it.m.remove(buckets, bucketID, it.pos)
+	}
+	it.mods = it.m.mods.snapshot()
+	return elem
+}
+
+// nextSlot advances the iterator to the next full slot of the open
+// addressing backend.
+func (it *MapIterator[K, V]) nextSlot() bool {
+	t := it.m.swiss
+	if it.ready {
+		it.mapPos++
+	}
+	for ; it.mapPos < uint64(len(t.slots)); it.mapPos++ {
+		if t.isFull(int(it.mapPos)) {
+			it.ready = true
+			return true
+		}
+	}
+	it.ready = false
+	return false
+}
+
+// bucket returns the bucket array and index of the current bucket.
+// While the map is resizing, the old buckets are visited first.
+func (it *MapIterator[K, V]) bucket() ([][]MapElement[K, V], uint64) {
+	if it.mapPos < uint64(len(it.m.oldBuckets)) {
+		return it.m.oldBuckets, it.mapPos
+	}
+	return it.m.buckets, it.mapPos - uint64(len(it.m.oldBuckets))
 }
 
 // Reset resets the iterator to the beginning of the map.
 func (it *MapIterator[K, V]) Reset() {
+	if it.m != nil {
+		it.mods = it.m.mods.snapshot()
+	}
 	it.mapPos = 0
 	it.pos = 0
 	it.ready = false
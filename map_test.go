@@ -9,8 +9,29 @@ import (
 	"github.com/ronanh/genmap"
 )
 
+var backends = []struct {
+	name string
+	opts []genmap.MapOption
+}{
+	{"chaining", nil},
+	{"open addressing", []genmap.MapOption{genmap.WithBackend(genmap.OpenAddressing)}},
+}
+
+// forEachBackend runs test as a subtest for each map backend.
+func forEachBackend(t *testing.T, test func(t *testing.T, opts ...genmap.MapOption)) {
+	for _, b := range backends {
+		t.Run(b.name, func(t *testing.T) {
+			test(t, b.opts...)
+		})
+	}
+}
+
 func TestMap(t *testing.T) {
-	m := genmap.NewMap[string, int](genmap.Equal[string], genmap.NewHasher[string]())
+	forEachBackend(t, testMap)
+}
+
+func testMap(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[string, int](genmap.Equal[string], genmap.NewHasher[string](), opts...)
 	if m.Len() != 0 {
 		t.Errorf("expected empty map, got %d elements", m.Len())
 	}
@@ -69,7 +90,11 @@ func TestMap(t *testing.T) {
 }
 
 func TestMapGet(t *testing.T) {
-	m := genmap.NewMap[int, string](genmap.Equal[int], genmap.NewHasher[int]())
+	forEachBackend(t, testMapGet)
+}
+
+func testMapGet(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[int, string](genmap.Equal[int], genmap.NewHasher[int](), opts...)
 	m.Upsert(1, func(elem *genmap.MapElement[int, string], exists bool) {
 		elem.Value = "one"
 	})
@@ -123,6 +148,18 @@ func BenchmarkMapGet(b *testing.B) {
 	}
 }
 
+func BenchmarkSwissMapGet(b *testing.B) {
+	m, keys := initSwissMapAndKeys(100000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		me, ok := m.Get(keys[i%100000])
+		if ok {
+			_ = me
+		}
+	}
+}
+
 func BenchmarkStdMapGet(b *testing.B) {
 	m, keys := initStdMapAndKeys(100000)
 
@@ -135,7 +172,11 @@ func BenchmarkStdMapGet(b *testing.B) {
 }
 
 func TestMapPut(t *testing.T) {
-	m := genmap.NewMap[MyKey, MyValue](MyKeyEquals, NewMyKeyHasher())
+	forEachBackend(t, testMapPut)
+}
+
+func testMapPut(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[MyKey, MyValue](MyKeyEquals, NewMyKeyHasher(), opts...)
 	m.Put(MyKey{1, nil}, MyValue{1, "a"})
 	m.Put(MyKey{2, nil}, MyValue{2, "b"})
 	m.Put(MyKey{3, nil}, MyValue{3, "c"})
@@ -154,7 +195,11 @@ func TestMapPut(t *testing.T) {
 }
 
 func TestMapUpsert(t *testing.T) {
-	m := genmap.NewMap[string, int](genmap.Equal[string], genmap.NewHasher[string]())
+	forEachBackend(t, testMapUpsert)
+}
+
+func testMapUpsert(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[string, int](genmap.Equal[string], genmap.NewHasher[string](), opts...)
 	m.Upsert("a", func(elem *genmap.MapElement[string, int], exists bool) {
 		elem.Value = 1
 	})
@@ -206,6 +251,17 @@ func BenchmarkMapPut100k(b *testing.B) {
 	}
 }
 
+func BenchmarkSwissMapPut100k(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := genmap.NewMapWithOptions[int, MyValue](genmap.Equal[int], genmap.NewHasher[int](),
+			genmap.WithBackend(genmap.OpenAddressing), genmap.WithCapacity(64<<10))
+		for j := 0; j < 100000; j++ {
+			m.Put(j, MyValue{j, "a"})
+		}
+	}
+}
+
 func BenchmarkStdMapPut100k(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
@@ -224,6 +280,14 @@ func BenchmarkMapPutOverwrite(b *testing.B) {
 	}
 }
 
+func BenchmarkSwissMapPutOverwrite(b *testing.B) {
+	m, keys := initSwissMapAndKeys(100000)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m.Put(keys[i%100000], MyValue{i, "a"})
+	}
+}
+
 func BenchmarkStdMapPutOverwrite(b *testing.B) {
 	m, keys := initStdMapAndKeys(100000)
 	b.ResetTimer()
@@ -312,6 +376,16 @@ func BenchmarkStdMapUpsertDelete(b *testing.B) {
 
 func initMapAndKeys(size, bucketsSize int) (*genmap.Map[string, MyValue], []string) {
 	m := genmap.NewMap[string, MyValue](genmap.Equal[string], genmap.NewHasher[string](), bucketsSize)
+	return m, fillMap(m, size)
+}
+
+func initSwissMapAndKeys(size int) (*genmap.Map[string, MyValue], []string) {
+	m := genmap.NewMapWithOptions[string, MyValue](genmap.Equal[string], genmap.NewHasher[string](),
+		genmap.WithBackend(genmap.OpenAddressing), genmap.WithCapacity(size))
+	return m, fillMap(m, size)
+}
+
+func fillMap(m *genmap.Map[string, MyValue], size int) []string {
 	keys := make([]string, size)
 	for i := 0; i < size; i++ {
 		v := rand.Int()
@@ -322,7 +396,7 @@ func initMapAndKeys(size, bucketsSize int) (*genmap.Map[string, MyValue], []stri
 		keys[i] = k
 	}
 	rand.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
-	return m, keys
+	return keys
 }
 
 func initStdMapAndKeys(size int) (map[string]MyValue, []string) {
@@ -339,7 +413,11 @@ func initStdMapAndKeys(size int) (map[string]MyValue, []string) {
 }
 
 func TestMapIterator(t *testing.T) {
-	m := genmap.NewMap[int, string](genmap.Equal[int], genmap.NewHasher[int]())
+	forEachBackend(t, testMapIterator)
+}
+
+func testMapIterator(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[int, string](genmap.Equal[int], genmap.NewHasher[int](), opts...)
 	m.Upsert(1, func(elem *genmap.MapElement[int, string], exists bool) {
 		elem.Value = "one"
 	})
@@ -469,3 +547,82 @@ func BenchmarkStdMapIterator(b *testing.B) {
 		}
 	}
 }
+
+func TestMapResize(t *testing.T) {
+	forEachBackend(t, testMapResize)
+}
+
+func testMapResize(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[int, int](genmap.Equal[int], genmap.NewHasher[int](), append(opts, genmap.WithCapacity(1))...)
+	const n = 10000
+	for i := 0; i < n; i++ {
+		if i%2 == 0 {
+			m.Put(i, i)
+		} else {
+			m.Upsert(i, func(elem *genmap.MapElement[int, int], exists bool) {
+				if exists {
+					t.Errorf("unexpected existing key %d", i)
+				}
+				elem.Value = i
+			})
+		}
+		// check a previously inserted key while the map may be resizing
+		if v, ok := m.Get(i / 2); !ok || v != i/2 {
+			t.Fatalf("expected value %d for key %d, got %v", i/2, i/2, v)
+		}
+	}
+	if m.Len() != n {
+		t.Errorf("expected length %d, got %d", n, m.Len())
+	}
+
+	seen := make(map[int]bool, n)
+	it := m.Iterator()
+	for it.Next() {
+		if seen[it.Cur().Key] {
+			t.Errorf("key %d visited twice", it.Cur().Key)
+		}
+		seen[it.Cur().Key] = true
+	}
+	if len(seen) != n {
+		t.Errorf("expected %d iterations, got %d", n, len(seen))
+	}
+
+	for i := 0; i < n-10; i++ {
+		if elem, ok := m.Remove(i); !ok || elem.Value != i {
+			t.Fatalf("expected removed value %d for key %d, got %v", i, i, elem)
+		}
+	}
+	if m.Len() != 10 {
+		t.Errorf("expected length 10, got %d", m.Len())
+	}
+	for i := 0; i < n; i++ {
+		v, ok := m.Get(i)
+		if ok != (i >= n-10) || (ok && v != i) {
+			t.Errorf("unexpected value %v (%v) for key %d", v, ok, i)
+		}
+	}
+}
+
+func TestMapValueAccess(t *testing.T) {
+	forEachBackend(t, testMapValueAccess)
+}
+
+func testMapValueAccess(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[string, int](genmap.Equal[string], genmap.NewHasher[string](), opts...)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Entry("a").OrDefault().MutateValue(func(v *int) { *v += 10 })
+
+	it := m.Iterator()
+	for it.Next() {
+		if it.CurKey() == "b" {
+			*it.CurValue() += 20
+		}
+	}
+	if v, _ := m.Get("a"); v != 11 {
+		t.Errorf("expected 11, got %d", v)
+	}
+	if v, _ := m.Get("b"); v != 22 {
+		t.Errorf("expected 22, got %d", v)
+	}
+}
@@ -0,0 +1,29 @@
+//go:build !genmapdebug
+
+package genmap
+
+// Concurrent modification and key mutation detection are only enabled with
+// the genmapdebug build tag. Without it, the types below are empty and their
+// methods are no-ops, so that the checks are compiled out.
+
+// debug reports whether the genmapdebug build tag is set.
+const debug = false
+
+// modCount counts the structural modifications of a map.
+type modCount struct{}
+
+func (*modCount) inc() {}
+
+func (*modCount) snapshot() modSnapshot { return modSnapshot{} }
+
+// modSnapshot records the modification count of a map at a given time.
+type modSnapshot struct{}
+
+func (modSnapshot) check(string) {}
+
+// keyGuard records the hash of the key of an element handed out to the user.
+type keyGuard[K any] struct{}
+
+func newKeyGuard[K any](hash func(K) uint64, h uint64) keyGuard[K] { return keyGuard[K]{} }
+
+func (keyGuard[K]) check(K) {}
@@ -0,0 +1,45 @@
+//go:build genmapdebug
+
+package genmap
+
+// debug reports whether the genmapdebug build tag is set.
+const debug = true
+
+// modCount counts the structural modifications of a map: insertions and
+// removals of elements, which may move the other elements in memory.
+type modCount uint64
+
+func (c *modCount) inc() { *c++ }
+
+func (c *modCount) snapshot() modSnapshot { return modSnapshot{c, *c} }
+
+// modSnapshot records the modification count of a map at a given time.
+type modSnapshot struct {
+	c *modCount
+	n modCount
+}
+
+// check panics with msg if the map was modified since the snapshot was taken.
+func (s modSnapshot) check(msg string) {
+	if s.c != nil && *s.c != s.n {
+		panic(msg)
+	}
+}
+
+// keyGuard records the hash of the key of an element handed out to the user.
+type keyGuard[K any] struct {
+	hash func(K) uint64
+	h    uint64
+}
+
+func newKeyGuard[K any](hash func(K) uint64, h uint64) keyGuard[K] {
+	return keyGuard[K]{hash, h}
+}
+
+// check panics if the key does not have the recorded hash anymore, i.e. it was
+// modified in place.
+func (g keyGuard[K]) check(key K) {
+	if g.hash != nil && g.hash(key) != g.h {
+		panic(errKeyModified)
+	}
+}
@@ -0,0 +1,152 @@
+//go:build genmapdebug
+
+package genmap_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+// debugBuild reports whether the genmapdebug build tag is set.
+const debugBuild = true
+
+func expectPanic(t *testing.T, msg string, f func()) {
+	t.Helper()
+	defer func() {
+		if r := recover(); r != msg {
+			t.Errorf("expected panic %q, got %v", msg, r)
+		}
+	}()
+	f()
+}
+
+func TestMapIteratorModified(t *testing.T) {
+	forEachBackend(t, testMapIteratorModified)
+}
+
+func testMapIteratorModified(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[int, int](genmap.Equal[int], genmap.NewHasher[int](), opts...)
+	for i := 0; i < 10; i++ {
+		m.Put(i, i)
+	}
+
+	it := m.Iterator()
+	it.Next()
+	m.Put(1, 2) // overwriting a value is not a structural modification
+	it.Cur()
+	it.Remove() // removing through the iterator is allowed
+	it.Next()
+
+	m.Put(100, 100)
+	expectPanic(t, "map modified during iteration", func() { it.Cur() })
+	expectPanic(t, "map modified during iteration", func() { it.Next() })
+
+	it.Reset()
+	it.Next()
+	m.Remove(100)
+	expectPanic(t, "map modified during iteration", func() { it.Next() })
+
+	it.Reset()
+	it.Next()
+	m.Clear()
+	expectPanic(t, "map modified during iteration", func() { it.Next() })
+}
+
+func TestMapEntryModified(t *testing.T) {
+	forEachBackend(t, testMapEntryModified)
+}
+
+func testMapEntryModified(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[int, int](genmap.Equal[int], genmap.NewHasher[int](), opts...)
+	m.Put(1, 1)
+
+	maybe := m.Entry(1)
+	entry := maybe.OrDefault()
+	m.Put(2, 2)
+	expectPanic(t, "map modified since the entry was created", func() {
+		entry.MutateWith(func(elem *genmap.MapElement[int, int]) {})
+	})
+
+	maybe = m.Entry(3)
+	m.Remove(2)
+	expectPanic(t, "map modified since the entry was created", func() { maybe.OrDefault() })
+
+	maybe = m.Entry(1)
+	m.Put(4, 4)
+	expectPanic(t, "map modified since the entry was created", func() { maybe.Insert(2) })
+	expectPanic(t, "map modified since the entry was created", func() { maybe.Remove() })
+
+	// a fresh entry remains usable after its own insertion
+	maybe = m.Entry(3)
+	maybe.OrDefault().MutateWith(func(elem *genmap.MapElement[int, int]) {
+		elem.Value = 3
+	})
+	if v, ok := m.Get(3); !ok || v != 3 {
+		t.Errorf("expected value 3, got %v", v)
+	}
+}
+
+func TestMapAllModified(t *testing.T) {
+	m := genmap.NewMap[int, int](genmap.Equal[int], genmap.NewHasher[int](), 1)
+	for i := 0; i < 10; i++ {
+		m.Put(i, i)
+	}
+	// removing the current element is allowed
+	for k := range m.Keys() {
+		m.Remove(k)
+	}
+	m.Put(1, 1)
+	expectPanic(t, "map modified during iteration", func() {
+		for k := range m.Keys() {
+			m.Put(k+1, 0)
+		}
+	})
+}
+
+func TestMapKeyModified(t *testing.T) {
+	forEachBackend(t, testMapKeyModified)
+}
+
+func testMapKeyModified(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[[]int, int](slices.Equal[[]int], genmap.NewAutoHasher[[]int](), opts...)
+	m.Put([]int{1}, 1)
+	m.Put([]int{2}, 2)
+
+	expectPanic(t, "map key modified in place", func() {
+		m.Upsert([]int{1}, func(elem *genmap.MapElement[[]int, int], exists bool) {
+			elem.Key = []int{3}
+		})
+	})
+	m.Clear()
+	m.Put([]int{1}, 1)
+	expectPanic(t, "map key modified in place", func() {
+		it := m.Iterator()
+		for it.Next() {
+			it.Cur().Key[0] = 3
+		}
+	})
+	m.Clear()
+	m.Put([]int{1}, 1)
+	expectPanic(t, "map key modified in place", func() {
+		for elem := range m.Elements() {
+			elem.Key = nil
+		}
+	})
+
+	// modifying values is allowed
+	m.Clear()
+	m.Put([]int{1}, 1)
+	m.Entry([]int{1}).OrDefault().MutateValue(func(v *int) { *v++ })
+	for elem := range m.Elements() {
+		elem.Value++
+	}
+	it := m.Iterator()
+	for it.Next() {
+		*it.CurValue()++
+	}
+	if v, _ := m.Get([]int{1}); v != 4 {
+		t.Errorf("expected 4, got %d", v)
+	}
+}
@@ -0,0 +1,153 @@
+package genmap
+
+import (
+	"iter"
+	"slices"
+)
+
+// MultiMap is a Map associating each key with one or more values, in the
+// order they were added.
+// MultiMap instance should be instantiated using the NewMultiMap function.
+type MultiMap[K any, V any] struct {
+	m          *Map[K, []V]
+	valueEqual func(v1, v2 V) bool
+	len        int
+}
+
+// NewMultiMap returns a new instance of MultiMap[K, V] with the given
+// equality and hash functions for the keys, and equality function for the
+// values (used by RemoveOne). The options configure the underlying Map.
+func NewMultiMap[K any, V any](equal func(k1, k2 K) bool, hash func(k K) uint64, valueEqual func(v1, v2 V) bool, opts ...MapOption) *MultiMap[K, V] {
+	return &MultiMap[K, V]{
+		m:          NewMapWithOptions[K, []V](equal, hash, opts...),
+		valueEqual: valueEqual,
+	}
+}
+
+// Len returns the total number of values in the map.
+func (mm *MultiMap[K, V]) Len() int {
+	if mm == nil {
+		return 0
+	}
+	return mm.len
+}
+
+// KeyCount returns the number of distinct keys in the map.
+func (mm *MultiMap[K, V]) KeyCount() int {
+	if mm == nil {
+		return 0
+	}
+	return mm.m.Len()
+}
+
+// Clear removes all keys and values from the map.
+func (mm *MultiMap[K, V]) Clear() {
+	mm.m.Clear()
+	mm.len = 0
+}
+
+// Add appends the given value to the values of the given key.
+func (mm *MultiMap[K, V]) Add(key K, val V) {
+	entry := mm.m.Entry(key)
+	entry.OrDefault().MutateValue(func(values *[]V) {
+		*values = append(*values, val)
+	})
+	mm.len++
+}
+
+// GetAll returns the values of the given key, in the order they were added,
+// nil if the key is absent. The returned slice must not be modified.
+func (mm *MultiMap[K, V]) GetAll(key K) []V {
+	if mm == nil {
+		return nil
+	}
+	values, _ := mm.m.Get(key)
+	return slices.Clip(values)
+}
+
+// CountFor returns the number of values of the given key.
+func (mm *MultiMap[K, V]) CountFor(key K) int {
+	return len(mm.GetAll(key))
+}
+
+// RemoveOne removes the first value of the given key equal to val. The key is
+// removed with its last value.
+// It returns false if no such value was found.
+func (mm *MultiMap[K, V]) RemoveOne(key K, val V) bool {
+	if mm == nil {
+		return false
+	}
+	entry := mm.m.Entry(key)
+	values := entry.ValuePtr()
+	if values == nil {
+		return false
+	}
+	i := slices.IndexFunc(*values, func(v V) bool { return mm.valueEqual(v, val) })
+	if i < 0 {
+		return false
+	}
+	mm.len--
+	if len(*values) == 1 {
+		entry.Remove()
+		return true
+	}
+	// a new slice, since the current one may have been returned by GetAll
+	*values = slices.Concat((*values)[:i], (*values)[i+1:])
+	return true
+}
+
+// RemoveAll removes the given key and returns its values, nil if the key was absent.
+func (mm *MultiMap[K, V]) RemoveAll(key K) []V {
+	removed, ok := mm.m.Remove(key)
+	if !ok {
+		return nil
+	}
+	mm.len -= len(removed.Value)
+	return removed.Value
+}
+
+// All returns an iterator over the keys of the map and their values.
+// The values must not be modified. The current key may be removed with
+// RemoveAll during the iteration; see Map.All.
+func (mm *MultiMap[K, V]) All() iter.Seq2[K, []V] {
+	return func(yield func(K, []V) bool) {
+		if mm == nil {
+			return
+		}
+		for k, values := range mm.m.All() {
+			if !yield(k, slices.Clip(values)) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the distinct keys of the map.
+// See All for the modifications allowed during the iteration.
+func (mm *MultiMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		if mm == nil {
+			return
+		}
+		for k := range mm.m.Keys() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Pairs returns an iterator over all the key-value pairs of the map, the
+// values of a key being yielded in the order they were added.
+// See All for the modifications allowed during the iteration.
+func (mm *MultiMap[K, V]) Pairs() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for k, values := range mm.All() {
+			for _, v := range values {
+				if !yield(k, v) {
+					return
+				}
+			}
+		}
+	}
+}
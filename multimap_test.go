@@ -0,0 +1,91 @@
+package genmap_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func TestMultiMap(t *testing.T) {
+	mm := genmap.NewMultiMap[[]string, int](slices.Equal[[]string], genmap.NewAutoHasher[[]string](), genmap.Equal[int])
+	mm.Add([]string{"a", "b"}, 1)
+	mm.Add([]string{"a", "b"}, 2)
+	mm.Add([]string{"c"}, 3)
+	mm.Add([]string{"a", "b"}, 1)
+	if mm.Len() != 4 || mm.KeyCount() != 2 {
+		t.Fatalf("expected 4 values and 2 keys, got %d and %d", mm.Len(), mm.KeyCount())
+	}
+	if got := mm.GetAll([]string{"a", "b"}); !slices.Equal(got, []int{1, 2, 1}) {
+		t.Errorf("unexpected values %v", got)
+	}
+	if mm.CountFor([]string{"c"}) != 1 || mm.CountFor([]string{"d"}) != 0 || mm.GetAll([]string{"d"}) != nil {
+		t.Error("unexpected count")
+	}
+
+	// appending to the returned slice must not change the map
+	_ = append(mm.GetAll([]string{"c"}), 4)
+	mm.Add([]string{"c"}, 5)
+	if got := mm.GetAll([]string{"c"}); !slices.Equal(got, []int{3, 5}) {
+		t.Errorf("unexpected values %v", got)
+	}
+
+	before := mm.GetAll([]string{"a", "b"})
+	if !mm.RemoveOne([]string{"a", "b"}, 1) || mm.RemoveOne([]string{"a", "b"}, 3) || mm.RemoveOne([]string{"d"}, 1) {
+		t.Error("unexpected RemoveOne result")
+	}
+	// slices returned by GetAll are not modified by RemoveOne
+	if !slices.Equal(before, []int{1, 2, 1}) {
+		t.Errorf("unexpected values %v returned before RemoveOne", before)
+	}
+	if got := mm.GetAll([]string{"a", "b"}); !slices.Equal(got, []int{2, 1}) {
+		t.Errorf("unexpected values %v", got)
+	}
+	if mm.Len() != 4 {
+		t.Errorf("expected 4 values, got %d", mm.Len())
+	}
+
+	var pairs []int
+	for k, v := range mm.Pairs() {
+		if len(k) == 1 {
+			pairs = append(pairs, v)
+		}
+	}
+	if !slices.Equal(pairs, []int{3, 5}) {
+		t.Errorf("unexpected pairs %v", pairs)
+	}
+	groups := 0
+	for k, values := range mm.All() {
+		groups++
+		if len(values) != mm.CountFor(k) {
+			t.Errorf("unexpected values %v for %v", values, k)
+		}
+	}
+	if groups != 2 || len(slices.Collect(mm.Keys())) != 2 {
+		t.Errorf("expected 2 key groups, got %d", groups)
+	}
+
+	// removing the last value removes the key
+	mm.RemoveOne([]string{"c"}, 3)
+	mm.RemoveOne([]string{"c"}, 5)
+	if mm.KeyCount() != 1 || mm.Len() != 2 {
+		t.Errorf("expected 2 values and 1 key, got %d and %d", mm.Len(), mm.KeyCount())
+	}
+	if got := mm.RemoveAll([]string{"a", "b"}); !slices.Equal(got, []int{2, 1}) {
+		t.Errorf("unexpected removed values %v", got)
+	}
+	if mm.Len() != 0 || mm.KeyCount() != 0 || mm.RemoveAll([]string{"a", "b"}) != nil {
+		t.Error("expected empty map")
+	}
+
+	mm.Add([]string{"x"}, 1)
+	mm.Clear()
+	if mm.Len() != 0 || mm.KeyCount() != 0 {
+		t.Error("expected empty map")
+	}
+
+	var nilMap *genmap.MultiMap[string, int]
+	if nilMap.Len() != 0 || nilMap.GetAll("a") != nil || nilMap.RemoveOne("a", 1) {
+		t.Error("unexpected values in a nil map")
+	}
+}
@@ -0,0 +1,6 @@
+//go:build !genmapdebug
+
+package genmap_test
+
+// debugBuild reports whether the genmapdebug build tag is set.
+const debugBuild = false
@@ -0,0 +1,177 @@
+package genmap
+
+import "math"
+
+// defaultBucketsSize is the number of buckets used when neither a bucket size
+// nor a capacity is provided.
+const defaultBucketsSize = 64 << 10
+
+// GrowthPolicy controls how the number of buckets of a Map follows its
+// number of elements.
+type GrowthPolicy int
+
+const (
+	// GrowAndShrink grows the buckets when the load factor exceeds the maximum
+	// load factor, and shrinks them back (never below the initial size) when
+	// most elements are removed. This is the default.
+	GrowAndShrink GrowthPolicy = iota
+	// GrowOnly grows the buckets but never shrinks them.
+	GrowOnly
+	// FixedSize keeps the initial number of buckets.
+	FixedSize
+)
+
+// Backend selects the data structure holding the elements of a Map.
+type Backend int
+
+const (
+	// Chaining stores the elements in buckets of colliding elements.
+	// This is the default.
+	Chaining Backend = iota
+	// OpenAddressing stores the elements in a single array of slots probed by
+	// groups of 8 (Swiss table style). It is usually faster for lookups and
+	// inserts, but rehashes the whole table at once when growing.
+	// WithMaxLoadFactor does not apply to this backend, and FixedSize is
+	// treated as GrowOnly since the table must grow when full.
+	OpenAddressing
+)
+
+// MapOption configures a Map created with NewMapWithOptions.
+type MapOption func(*mapOptions)
+
+type mapOptions struct {
+	bucketsSize   int
+	capacity      int
+	maxLoadFactor float64
+	growthPolicy  GrowthPolicy
+	backend       Backend
+	jsonObject    bool
+}
+
+// WithCapacity sets the expected number of elements in the map.
+// The number of buckets is derived from it so that the map holds capacity
+// elements without resizing, and the element storage is preallocated.
+func WithCapacity(capacity int) MapOption {
+	if capacity < 0 {
+		panic("negative capacity")
+	}
+	return func(o *mapOptions) {
+		o.capacity = capacity
+	}
+}
+
+// WithMaxLoadFactor sets the average number of elements per bucket above which
+// the buckets are grown. The default is 2.
+func WithMaxLoadFactor(loadFactor float64) MapOption {
+	if !(loadFactor > 0) {
+		panic("load factor must be positive")
+	}
+	return func(o *mapOptions) {
+		o.maxLoadFactor = loadFactor
+	}
+}
+
+// WithGrowthPolicy sets the growth policy of the map. The default is GrowAndShrink.
+func WithGrowthPolicy(policy GrowthPolicy) MapOption {
+	return func(o *mapOptions) {
+		o.growthPolicy = policy
+	}
+}
+
+// WithBackend sets the backend of the map. The default is Chaining.
+// When no capacity is provided, the OpenAddressing backend starts small
+// rather than with the default 64k buckets.
+func WithBackend(backend Backend) MapOption {
+	return func(o *mapOptions) {
+		o.backend = backend
+	}
+}
+
+// WithJSONObject makes the map encode to JSON as an object rather than as an
+// array of key-value pairs. The key type must be a string type or implement
+// encoding.TextMarshaler (and encoding.TextUnmarshaler through a pointer to be
+// decoded, unless it is a string type).
+func WithJSONObject() MapOption {
+	return func(o *mapOptions) {
+		o.jsonObject = true
+	}
+}
+
+// NewMapWithOptions returns a new instance of Map[K, V] with the given equality
+// and hash functions, configured by the given options.
+//
+// Example:
+//
+//	m := genmap.NewMapWithOptions[string, int](genmap.Equal[string], genmap.NewHasher[string](),
+//	    genmap.WithCapacity(1000),
+//	    genmap.WithGrowthPolicy(genmap.GrowOnly),
+//	)
+func NewMapWithOptions[K any, V any](equal func(k1, k2 K) bool, hash func(k K) uint64, opts ...MapOption) *Map[K, V] {
+	o := mapOptions{maxLoadFactor: defaultMaxLoadFactor}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return newMap[K, V](equal, hash, o)
+}
+
+func newMap[K any, V any](equal func(k1, k2 K) bool, hash func(k K) uint64, o mapOptions) *Map[K, V] {
+	if o.backend == OpenAddressing {
+		return &Map[K, V]{
+			equal:        equal,
+			hash:         hash,
+			growthPolicy: o.growthPolicy,
+			jsonObject:   o.jsonObject,
+			swiss:        newSwissTable[K, V](equal, o.capacity),
+		}
+	}
+	bucketsSize := o.bucketsSize
+	if bucketsSize == 0 {
+		bucketsSize = defaultBucketsSize
+		if o.capacity > 0 {
+			// fill the buckets up to half the max load factor
+			bucketsSize = int(math.Ceil(2 * float64(o.capacity) / o.maxLoadFactor))
+		}
+	}
+	if bucketsSize < 1 {
+		bucketsSize = 1
+	}
+
+	m := &Map[K, V]{
+		equal:         equal,
+		hash:          hash,
+		buckets:       make([][]MapElement[K, V], bucketsSize),
+		maxLoadFactor: o.maxLoadFactor,
+		growthPolicy:  o.growthPolicy,
+		minBuckets:    bucketsSize,
+		jsonObject:    o.jsonObject,
+	}
+	if o.capacity > 0 {
+		// single element buckets take 1 slot, the others at least 4
+		m.allocBuffer = make([]MapElement[K, V], o.capacity+o.capacity/2)
+	}
+	m.setThresholds()
+	return m
+}
+
+// derivedMinCapacity is the minimum capacity of the maps created by
+// newMapLike, so that a map derived from an empty one still has a few buckets.
+const derivedMinCapacity = 8
+
+// newMapLike returns a new empty map with the functions and configuration of
+// m, sized for capacity elements.
+func newMapLike[K any, V any, V2 any](m *Map[K, V], capacity int) *Map[K, V2] {
+	o := mapOptions{
+		capacity:      max(capacity, derivedMinCapacity),
+		maxLoadFactor: m.maxLoadFactor,
+		growthPolicy:  m.growthPolicy,
+		jsonObject:    m.jsonObject,
+	}
+	if o.growthPolicy == FixedSize {
+		// the size of m does not suit the new map, which must be able to grow
+		o.growthPolicy = GrowAndShrink
+	}
+	if m.swiss != nil {
+		o.backend = OpenAddressing
+	}
+	return newMap[K, V2](m.equal, m.hash, o)
+}
@@ -0,0 +1,70 @@
+package genmap_test
+
+import (
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func TestNewMapWithOptions(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []genmap.MapOption
+	}{
+		{"default", nil},
+		{"capacity", []genmap.MapOption{genmap.WithCapacity(100)}},
+		{"zero capacity", []genmap.MapOption{genmap.WithCapacity(0)}},
+		{"load factor", []genmap.MapOption{genmap.WithCapacity(10), genmap.WithMaxLoadFactor(0.5)}},
+		{"grow only", []genmap.MapOption{genmap.WithCapacity(1), genmap.WithGrowthPolicy(genmap.GrowOnly)}},
+		{"fixed size", []genmap.MapOption{genmap.WithCapacity(1), genmap.WithGrowthPolicy(genmap.FixedSize)}},
+		{"open addressing", []genmap.MapOption{genmap.WithBackend(genmap.OpenAddressing)}},
+		{"open addressing fixed size", []genmap.MapOption{genmap.WithBackend(genmap.OpenAddressing), genmap.WithGrowthPolicy(genmap.FixedSize)}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := genmap.NewMapWithOptions[int, int](genmap.Equal[int], genmap.NewHasher[int](), tt.opts...)
+			for i := 0; i < 1000; i++ {
+				m.Put(i, i*2)
+			}
+			for i := 0; i < 1000; i += 3 {
+				m.Remove(i)
+			}
+			for i := 0; i < 1000; i++ {
+				v, ok := m.Get(i)
+				if ok != (i%3 != 0) || (ok && v != i*2) {
+					t.Errorf("unexpected value %v (%v) for key %d", v, ok, i)
+				}
+			}
+			if m.Len() != 666 {
+				t.Errorf("expected length 666, got %d", m.Len())
+			}
+		})
+	}
+}
+
+func TestNewMapWithOptionsInvalid(t *testing.T) {
+	for name, f := range map[string]func(){
+		"negative capacity":    func() { genmap.WithCapacity(-1) },
+		"zero load factor":     func() { genmap.WithMaxLoadFactor(0) },
+		"negative load factor": func() { genmap.WithMaxLoadFactor(-1) },
+	} {
+		t.Run(name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expected panic")
+				}
+			}()
+			f()
+		})
+	}
+}
+
+func BenchmarkMapPut100kWithCapacity(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		m := genmap.NewMapWithOptions[int, MyValue](genmap.Equal[int], genmap.NewHasher[int](), genmap.WithCapacity(100000))
+		for j := 0; j < 100000; j++ {
+			m.Put(j, MyValue{j, "a"})
+		}
+	}
+}
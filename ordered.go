@@ -0,0 +1,392 @@
+package genmap
+
+import "iter"
+
+// Order is the iteration order of an OrderedMap.
+type Order int
+
+const (
+	// InsertionOrder iterates over the elements in the order their keys were
+	// first inserted. Overwriting the value of a key does not change its position.
+	InsertionOrder Order = iota
+	// AccessOrder iterates over the elements from the least recently accessed
+	// to the most recently accessed. Get, Put, Upsert and Entry count as accesses.
+	AccessOrder
+)
+
+// orderedNodesChunk is the number of nodes allocated at once.
+const orderedNodesChunk = 256
+
+type orderedNode[K any, V any] struct {
+	elem       MapElement[K, V]
+	prev, next *orderedNode[K, V]
+}
+
+// OrderedMap is a Map that iterates over its elements in a predictable order:
+// insertion order or access order.
+// The elements form a doubly linked list, the underlying Map indexing the nodes
+// of the list by key.
+// OrderedMap instance should be instantiated using the NewOrderedMap function.
+type OrderedMap[K any, V any] struct {
+	m     *Map[K, *orderedNode[K, V]]
+	order Order
+	// sentinel of the circular list: root.next is the first element and
+	// root.prev the last one
+	root       orderedNode[K, V]
+	nodeBuffer []orderedNode[K, V]
+	freeNodes  *orderedNode[K, V]
+}
+
+// NewOrderedMap returns a new instance of OrderedMap[K, V] with the given
+// equality and hash functions and iteration order.
+// The options configure the underlying Map.
+func NewOrderedMap[K any, V any](equal func(k1, k2 K) bool, hash func(k K) uint64, order Order, opts ...MapOption) *OrderedMap[K, V] {
+	om := &OrderedMap[K, V]{
+		m:     NewMapWithOptions[K, *orderedNode[K, V]](equal, hash, opts...),
+		order: order,
+	}
+	om.root.next = &om.root
+	om.root.prev = &om.root
+	return om
+}
+
+// Len returns the number of elements in the map.
+func (om *OrderedMap[K, V]) Len() int {
+	if om == nil {
+		return 0
+	}
+	return om.m.Len()
+}
+
+// Clear removes all elements from the map.
+func (om *OrderedMap[K, V]) Clear() {
+	om.m.Clear()
+	om.root.next = &om.root
+	om.root.prev = &om.root
+	om.nodeBuffer = nil
+	om.freeNodes = nil
+}
+
+// Get returns the value associated with the given key.
+func (om *OrderedMap[K, V]) Get(key K) (V, bool) {
+	if om == nil {
+		return *new(V), false
+	}
+	node, ok := om.m.Get(key)
+	if !ok {
+		return *new(V), false
+	}
+	om.accessed(node)
+	return node.elem.Value, true
+}
+
+// Put inserts the given key-value pair into the map.
+// A new key is added at the end of the iteration order.
+func (om *OrderedMap[K, V]) Put(key K, val V) {
+	entry := om.Entry(key)
+	entry.OrDefault().elem.Value = val
+}
+
+// Upsert inserts or modifies the given entry into the map.
+// The update function is called with the current value or the new one.
+func (om *OrderedMap[K, V]) Upsert(key K, update func(elem *MapElement[K, V], exists bool)) {
+	entry := om.Entry(key)
+	exists := entry.Exists()
+	entry.OrDefault().MutateWith(func(elem *MapElement[K, V]) {
+		update(elem, exists)
+	})
+}
+
+// Entry returns a MaybeOrderedEntry that provides optional access to the
+// element associated with the given key.
+func (om *OrderedMap[K, V]) Entry(key K) MaybeOrderedEntry[K, V] {
+	entry := om.m.Entry(key)
+	if entry.elem != nil {
+		om.accessed(entry.elem.Value)
+	}
+	return MaybeOrderedEntry[K, V]{om, entry}
+}
+
+// Remove removes the given key from the map and returns it.
+func (om *OrderedMap[K, V]) Remove(key K) (MapElement[K, V], bool) {
+	removed, ok := om.m.Remove(key)
+	if !ok {
+		return MapElement[K, V]{}, false
+	}
+	node := removed.Value
+	elem := node.elem
+	om.unlink(node)
+	om.freeNode(node)
+	return elem, true
+}
+
+// First returns the first element in iteration order.
+func (om *OrderedMap[K, V]) First() (MapElement[K, V], bool) {
+	if om.Len() == 0 {
+		return MapElement[K, V]{}, false
+	}
+	return om.root.next.elem, true
+}
+
+// Last returns the last element in iteration order.
+func (om *OrderedMap[K, V]) Last() (MapElement[K, V], bool) {
+	if om.Len() == 0 {
+		return MapElement[K, V]{}, false
+	}
+	return om.root.prev.elem, true
+}
+
+// MoveToFront moves the given key to the front of the iteration order.
+// It returns false if the key is not in the map.
+func (om *OrderedMap[K, V]) MoveToFront(key K) bool {
+	node, ok := om.m.Get(key)
+	if !ok {
+		return false
+	}
+	om.unlink(node)
+	om.linkAfter(node, &om.root)
+	return true
+}
+
+// MoveToBack moves the given key to the back of the iteration order.
+// It returns false if the key is not in the map.
+func (om *OrderedMap[K, V]) MoveToBack(key K) bool {
+	node, ok := om.m.Get(key)
+	if !ok {
+		return false
+	}
+	om.unlink(node)
+	om.linkAfter(node, om.root.prev)
+	return true
+}
+
+// All returns an iterator over the key-value pairs of the map, in order.
+//
+// The current element may be removed with Remove during the iteration, but
+// not the following ones.
+// Moving or accessing elements (in AccessOrder) during the iteration
+// changes the order, so that elements may be skipped or visited twice.
+func (om *OrderedMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for elem := range om.Elements() {
+			if !yield(elem.Key, elem.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys of the map, in order.
+// See All for the modifications allowed during the iteration.
+func (om *OrderedMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for elem := range om.Elements() {
+			if !yield(elem.Key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values of the map, in order.
+// See All for the modifications allowed during the iteration.
+func (om *OrderedMap[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for elem := range om.Elements() {
+			if !yield(elem.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Elements returns an iterator over pointers to the elements of the map, in
+// order, allowing values to be modified in place. The key of an element must
+// not be modified.
+// See All for the modifications allowed during the iteration.
+func (om *OrderedMap[K, V]) Elements() iter.Seq[*MapElement[K, V]] {
+	return func(yield func(*MapElement[K, V]) bool) {
+		if om == nil {
+			return
+		}
+		for node := om.root.next; node != &om.root; {
+			next := node.next
+			if !yield(&node.elem) {
+				return
+			}
+			node = next
+		}
+	}
+}
+
+// Backward returns an iterator over the key-value pairs of the map, in
+// reverse order.
+// See All for the modifications allowed during the iteration.
+func (om *OrderedMap[K, V]) Backward() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if om == nil {
+			return
+		}
+		for node := om.root.prev; node != &om.root; {
+			prev := node.prev
+			if !yield(node.elem.Key, node.elem.Value) {
+				return
+			}
+			node = prev
+		}
+	}
+}
+
+// accessed moves the node to the back in access order.
+func (om *OrderedMap[K, V]) accessed(node *orderedNode[K, V]) {
+	if om.order == AccessOrder && node != om.root.prev {
+		om.unlink(node)
+		om.linkAfter(node, om.root.prev)
+	}
+}
+
+func (om *OrderedMap[K, V]) unlink(node *orderedNode[K, V]) {
+	node.prev.next = node.next
+	node.next.prev = node.prev
+}
+
+func (om *OrderedMap[K, V]) linkAfter(node, at *orderedNode[K, V]) {
+	node.prev = at
+	node.next = at.next
+	at.next.prev = node
+	at.next = node
+}
+
+func (om *OrderedMap[K, V]) newNode() *orderedNode[K, V] {
+	if node := om.freeNodes; node != nil {
+		om.freeNodes = node.next
+		node.next = nil
+		return node
+	}
+	if len(om.nodeBuffer) == 0 {
+		om.nodeBuffer = make([]orderedNode[K, V], orderedNodesChunk)
+	}
+	node := &om.nodeBuffer[len(om.nodeBuffer)-1]
+	om.nodeBuffer = om.nodeBuffer[:len(om.nodeBuffer)-1]
+	return node
+}
+
+// freeNode clears the node and adds it to the free list.
+func (om *OrderedMap[K, V]) freeNode(node *orderedNode[K, V]) {
+	node.elem = MapElement[K, V]{}
+	node.prev = nil
+	node.next = om.freeNodes
+	om.freeNodes = node
+}
+
+// MaybeOrderedEntry represents the result of a lookup in an OrderedMap that
+// may be absent.
+type MaybeOrderedEntry[K any, V any] struct {
+	om    *OrderedMap[K, V]
+	entry MaybeMapEntry[K, *orderedNode[K, V]]
+}
+
+// Exists reports whether the lookup succeeded (i.e. an element was found).
+func (entry MaybeOrderedEntry[K, V]) Exists() bool {
+	return entry.entry.Exists()
+}
+
+// Key returns the key being looked up.
+func (entry MaybeOrderedEntry[K, V]) Key() K {
+	return entry.entry.Key()
+}
+
+// Value returns the value of the element, if it exists.
+func (entry MaybeOrderedEntry[K, V]) Value() (V, bool) {
+	node, ok := entry.entry.Value()
+	if !ok {
+		return *new(V), false
+	}
+	return node.elem.Value, true
+}
+
+// ValuePtr returns a pointer to the value of the element, nil if it does not
+// exist. The pointer must not be used after an insertion or removal in the map.
+func (entry MaybeOrderedEntry[K, V]) ValuePtr() *V {
+	if node := entry.entry.ValuePtr(); node != nil {
+		return &(*node).elem.Value
+	}
+	return nil
+}
+
+// OrDefault returns a concrete `MapEntry`.  If the element already exists it
+// is returned unchanged; otherwise a new element with a zero value is added at
+// the back of the map.
+// The entry must not be used after another write to the map.
+func (entry MaybeOrderedEntry[K, V]) OrDefault() MapEntry[K, V] {
+	exists := entry.entry.Exists()
+	mapEntry := entry.entry.OrDefault()
+	if !exists {
+		om := entry.om
+		node := om.newNode()
+		node.elem.Key = entry.entry.key
+		om.linkAfter(node, om.root.prev)
+		mapEntry.elem.Value = node
+	}
+	return MapEntry[K, V]{mapEntry.mods, newKeyGuard(entry.om.m.hash, entry.entry.hash), &mapEntry.elem.Value.elem}
+}
+
+// OrInsert returns the existing element, or adds one with the given value at
+// the back of the map.
+func (entry MaybeOrderedEntry[K, V]) OrInsert(val V) MapEntry[K, V] {
+	if entry.Exists() {
+		return entry.OrDefault()
+	}
+	e := entry.OrDefault()
+	e.elem.Value = val
+	return e
+}
+
+// OrInsertWith returns the existing element, or adds one with the value
+// returned by f at the back of the map. f is only called if the element does
+// not exist.
+func (entry MaybeOrderedEntry[K, V]) OrInsertWith(f func() V) MapEntry[K, V] {
+	if entry.Exists() {
+		return entry.OrDefault()
+	}
+	val := f()
+	e := entry.OrDefault()
+	e.elem.Value = val
+	return e
+}
+
+// AndModify calls f with a pointer to the value of the element if it exists,
+// and returns the entry for chaining with OrInsert, OrInsertWith or OrDefault.
+func (entry MaybeOrderedEntry[K, V]) AndModify(f func(*V)) MaybeOrderedEntry[K, V] {
+	if v := entry.ValuePtr(); v != nil {
+		f(v)
+	}
+	return entry
+}
+
+// Insert sets the value of the element, adding it at the back of the map if
+// it does not exist, and returns the previous value, if any.
+// The entry must not be used after another write to the map.
+func (entry MaybeOrderedEntry[K, V]) Insert(val V) (V, bool) {
+	if v := entry.ValuePtr(); v != nil {
+		old := *v
+		*v = val
+		return old, true
+	}
+	entry.OrDefault().elem.Value = val
+	return *new(V), false
+}
+
+// Remove removes the element, if it exists, without hashing the key again,
+// and returns its value.
+// The entry must not be used after another write to the map.
+func (entry MaybeOrderedEntry[K, V]) Remove() (V, bool) {
+	node, ok := entry.entry.Remove()
+	if !ok {
+		return *new(V), false
+	}
+	val := node.elem.Value
+	entry.om.unlink(node)
+	entry.om.freeNode(node)
+	return val, true
+}
@@ -0,0 +1,158 @@
+package genmap_test
+
+import (
+	"reflect"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func TestOrderedMap(t *testing.T) {
+	m := genmap.NewOrderedMap[MyKey, int](MyKeyEquals, NewMyKeyHasher(), genmap.InsertionOrder, genmap.WithCapacity(1))
+	for i := 0; i < 100; i++ {
+		m.Put(MyKey{i, []string{"a"}}, i)
+	}
+	m.Put(MyKey{0, []string{"a"}}, 42) // overwrite keeps the position
+	m.Upsert(MyKey{100, []string{"a"}}, func(elem *genmap.MapElement[MyKey, int], exists bool) {
+		if exists {
+			t.Errorf("unexpected existing key")
+		}
+		elem.Value = 100
+	})
+	if m.Len() != 101 {
+		t.Errorf("expected length 101, got %d", m.Len())
+	}
+	if v, ok := m.Get(MyKey{0, []string{"a"}}); !ok || v != 42 {
+		t.Errorf("expected value 42, got %v", v)
+	}
+
+	var keys []int
+	for k := range m.Keys() {
+		keys = append(keys, k.k1)
+	}
+	for i, k := range keys {
+		if k != i {
+			t.Fatalf("expected key %d at position %d, got %d", i, i, k)
+		}
+	}
+
+	// remove odd keys while iterating
+	for k := range m.Keys() {
+		if k.k1%2 == 1 {
+			m.Remove(k)
+		}
+	}
+	keys = keys[:0]
+	for k, v := range m.Backward() {
+		keys = append(keys, k.k1)
+		if k.k1 != 0 && v != k.k1 {
+			t.Errorf("expected value %d, got %d", k.k1, v)
+		}
+	}
+	if len(keys) != 51 || keys[0] != 100 || keys[50] != 0 {
+		t.Errorf("unexpected backward keys %v", keys)
+	}
+
+	if !m.MoveToFront(MyKey{50, []string{"a"}}) || !m.MoveToBack(MyKey{0, []string{"a"}}) {
+		t.Errorf("expected keys to be moved")
+	}
+	if m.MoveToFront(MyKey{1, []string{"a"}}) {
+		t.Errorf("unexpected move of a missing key")
+	}
+	if first, ok := m.First(); !ok || first.Key.k1 != 50 {
+		t.Errorf("expected first key 50, got %v", first)
+	}
+	if last, ok := m.Last(); !ok || last.Key.k1 != 0 || last.Value != 42 {
+		t.Errorf("expected last element {0 42}, got %v", last)
+	}
+
+	// removed nodes are reused
+	for i := 1; i < 100; i += 2 {
+		m.Put(MyKey{i, []string{"b"}}, i)
+	}
+	if last, _ := m.Last(); last.Key.k1 != 99 || last.Key.k2[0] != "b" {
+		t.Errorf("expected last key {99 [b]}, got %v", last.Key)
+	}
+
+	m.Clear()
+	if _, ok := m.First(); ok || m.Len() != 0 {
+		t.Errorf("expected empty map")
+	}
+	for range m.All() {
+		t.Errorf("unexpected element in empty map")
+	}
+}
+
+func TestOrderedMapAccessOrder(t *testing.T) {
+	m := genmap.NewOrderedMap[string, int](genmap.Equal[string], genmap.NewHasher[string](), genmap.AccessOrder)
+	m.Put("a", 1)
+	m.Put("b", 2)
+	m.Put("c", 3)
+	m.Get("a")
+	m.Put("b", 4)
+	entry := m.Entry("d")
+	entry.OrDefault().MutateWith(func(elem *genmap.MapElement[string, int]) {
+		elem.Value = 5
+	})
+
+	var keys []string
+	var values []int
+	for k, v := range m.All() {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	if !reflect.DeepEqual(keys, []string{"c", "a", "b", "d"}) || !reflect.DeepEqual(values, []int{3, 1, 4, 5}) {
+		t.Errorf("unexpected order %v %v", keys, values)
+	}
+
+	m.Upsert("c", func(elem *genmap.MapElement[string, int], exists bool) {
+		elem.Value++
+	})
+	keys = slices.Collect(m.Keys())
+	if !reflect.DeepEqual(keys, []string{"a", "b", "d", "c"}) {
+		t.Errorf("unexpected order %v", keys)
+	}
+	values = slices.Collect(m.Values())
+	if !reflect.DeepEqual(values, []int{1, 4, 5, 4}) {
+		t.Errorf("unexpected values %v", values)
+	}
+}
+
+func TestOrderedMapEntry(t *testing.T) {
+	m := genmap.NewOrderedMap[string, int](genmap.Equal[string], genmap.NewHasher[string](), genmap.InsertionOrder)
+	m.Entry("a").OrDefault()
+	m.Entry("b").OrInsert(2)
+	m.Entry("c").OrInsertWith(func() int { return 3 })
+	m.Entry("b").AndModify(func(v *int) { *v *= 10 }).OrInsert(0)
+	if old, ok := m.Entry("a").Insert(1); !ok || old != 0 {
+		t.Errorf("expected previous value 0, got %d, %v", old, ok)
+	}
+	if old, ok := m.Entry("d").Insert(4); ok || old != 0 {
+		t.Errorf("unexpected previous value %d, %v", old, ok)
+	}
+	if v, ok := m.Entry("b").Value(); !ok || v != 20 {
+		t.Errorf("expected value 20, got %d, %v", v, ok)
+	}
+	if p := m.Entry("missing").ValuePtr(); p != nil {
+		t.Errorf("unexpected value pointer for a missing key")
+	}
+	*m.Entry("c").ValuePtr() = 30
+	if e := m.Entry("c"); !e.Exists() || e.Key() != "c" {
+		t.Errorf("expected existing key c")
+	}
+	if v, ok := m.Entry("c").Remove(); !ok || v != 30 {
+		t.Errorf("expected removed value 30, got %d, %v", v, ok)
+	}
+	if _, ok := m.Entry("c").Remove(); ok {
+		t.Errorf("unexpected removal of a missing key")
+	}
+	var got []string
+	for k, v := range m.All() {
+		got = append(got, k+"="+strconv.Itoa(v))
+	}
+	if want := []string{"a=1", "b=20", "d=4"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
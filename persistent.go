@@ -0,0 +1,364 @@
+package genmap
+
+import (
+	"iter"
+	"math/bits"
+)
+
+// Hash array mapped trie (HAMT) in the CHAMP layout: each node indexes up to
+// 32 slots with 5 bits of the hash, and keeps the elements stored inline
+// (dataMap) apart from the sub-nodes (nodeMap). Nodes are kept in canonical
+// form: a sub-node always holds more than one element.
+// Once the 64 bits of the hash are consumed, colliding elements are stored in
+// a collision node, a plain list of elements.
+
+const (
+	hamtBits  = 5
+	hamtMask  = 1<<hamtBits - 1
+	hamtDepth = 64 // shift at which nodes become collision nodes
+)
+
+// editToken identifies the TransientMap owning a node, which can then be
+// modified in place.
+type editToken struct{ _ byte }
+
+type hamtNode[K any, V any] struct {
+	dataMap uint32
+	nodeMap uint32
+	data    []MapElement[K, V]
+	nodes   []*hamtNode[K, V]
+	edit    *editToken // owner, nil if the node is shared
+}
+
+// PersistentMap is an immutable map allowing any type for keys. Put and
+// Remove return a new version of the map, sharing most of its structure with
+// the previous one, which remains valid and unchanged.
+// Use Transient to apply many updates at once more efficiently.
+// Since it is immutable, PersistentMap is safe for concurrent use.
+// PersistentMap instance should be instantiated using the NewPersistentMap function.
+type PersistentMap[K any, V any] struct {
+	equal func(k1, k2 K) bool
+	hash  func(k K) uint64
+	root  *hamtNode[K, V]
+	len   int
+}
+
+// NewPersistentMap returns a new empty instance of PersistentMap[K, V] with the
+// given equality and hash functions.
+func NewPersistentMap[K any, V any](equal func(k1, k2 K) bool, hash func(k K) uint64) *PersistentMap[K, V] {
+	return &PersistentMap[K, V]{equal: equal, hash: hash, root: &hamtNode[K, V]{}}
+}
+
+// Len returns the number of elements in the map.
+func (pm *PersistentMap[K, V]) Len() int {
+	if pm == nil {
+		return 0
+	}
+	return pm.len
+}
+
+// Get returns the value associated with the given key.
+func (pm *PersistentMap[K, V]) Get(key K) (V, bool) {
+	if pm == nil {
+		return *new(V), false
+	}
+	return hamtGet(pm.root, pm.equal, pm.hash(key), key)
+}
+
+// Put returns a new version of the map with the given key-value pair.
+func (pm *PersistentMap[K, V]) Put(key K, val V) *PersistentMap[K, V] {
+	root, added := pm.root.put(pm.equal, nil, pm.hash(key), key, val, 0)
+	n := pm.len
+	if added {
+		n++
+	}
+	return &PersistentMap[K, V]{pm.equal, pm.hash, root, n}
+}
+
+// Remove returns a new version of the map without the given key, or pm
+// itself if the key is absent.
+func (pm *PersistentMap[K, V]) Remove(key K) *PersistentMap[K, V] {
+	root, removed := pm.root.remove(pm.equal, nil, pm.hash(key), key, 0)
+	if !removed {
+		return pm
+	}
+	return &PersistentMap[K, V]{pm.equal, pm.hash, root, pm.len - 1}
+}
+
+// All returns an iterator over the key-value pairs of the map.
+func (pm *PersistentMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if pm != nil {
+			pm.root.all(yield)
+		}
+	}
+}
+
+// Keys returns an iterator over the keys of the map.
+func (pm *PersistentMap[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range pm.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Transient returns a mutable copy of the map, to apply a batch of updates
+// without copying the nodes on every update. The map itself is not modified.
+func (pm *PersistentMap[K, V]) Transient() *TransientMap[K, V] {
+	return &TransientMap[K, V]{pm.equal, pm.hash, pm.root, pm.len, &editToken{}}
+}
+
+// TransientMap is a mutable version of a PersistentMap, returned by
+// PersistentMap.Transient. Its updates modify in place the nodes it has
+// already copied.
+// TransientMap is not safe for concurrent use.
+type TransientMap[K any, V any] struct {
+	equal func(k1, k2 K) bool
+	hash  func(k K) uint64
+	root  *hamtNode[K, V]
+	len   int
+	edit  *editToken
+}
+
+func (tm *TransientMap[K, V]) ensureEditable() {
+	if tm.edit == nil {
+		panic("transient map used after Persistent")
+	}
+}
+
+// Len returns the number of elements in the map.
+func (tm *TransientMap[K, V]) Len() int {
+	return tm.len
+}
+
+// Get returns the value associated with the given key.
+func (tm *TransientMap[K, V]) Get(key K) (V, bool) {
+	tm.ensureEditable()
+	return hamtGet(tm.root, tm.equal, tm.hash(key), key)
+}
+
+// Put inserts the given key-value pair into the map.
+func (tm *TransientMap[K, V]) Put(key K, val V) {
+	tm.ensureEditable()
+	root, added := tm.root.put(tm.equal, tm.edit, tm.hash(key), key, val, 0)
+	tm.root = root
+	if added {
+		tm.len++
+	}
+}
+
+// Remove removes the given key from the map.
+// It returns false if the key was not in the map.
+func (tm *TransientMap[K, V]) Remove(key K) bool {
+	tm.ensureEditable()
+	root, removed := tm.root.remove(tm.equal, tm.edit, tm.hash(key), key, 0)
+	tm.root = root
+	if removed {
+		tm.len--
+	}
+	return removed
+}
+
+// Persistent returns the PersistentMap holding the elements of the transient
+// map, which must not be used afterwards.
+func (tm *TransientMap[K, V]) Persistent() *PersistentMap[K, V] {
+	tm.ensureEditable()
+	// the nodes owned by the token are now shared
+	tm.edit = nil
+	return &PersistentMap[K, V]{tm.equal, tm.hash, tm.root, tm.len}
+}
+
+func hamtGet[K any, V any](n *hamtNode[K, V], equal func(k1, k2 K) bool, hash uint64, key K) (V, bool) {
+	for shift := 0; ; shift += hamtBits {
+		if shift >= hamtDepth {
+			for i := range n.data {
+				if equal(n.data[i].Key, key) {
+					return n.data[i].Value, true
+				}
+			}
+			return *new(V), false
+		}
+		bit := hamtBit(hash, shift)
+		if n.dataMap&bit != 0 {
+			e := &n.data[hamtIndex(n.dataMap, bit)]
+			if e.hash == hash && equal(e.Key, key) {
+				return e.Value, true
+			}
+			return *new(V), false
+		}
+		if n.nodeMap&bit == 0 {
+			return *new(V), false
+		}
+		n = n.nodes[hamtIndex(n.nodeMap, bit)]
+	}
+}
+
+// hamtBit returns the bit of the slot of hash in a node at the given shift.
+func hamtBit(hash uint64, shift int) uint32 {
+	return 1 << (hash >> shift & hamtMask)
+}
+
+// hamtIndex returns the index of the slot bit among the slots set in bitmap.
+func hamtIndex(bitmap, bit uint32) int {
+	return bits.OnesCount32(bitmap & (bit - 1))
+}
+
+// editable returns n if it is owned by edit, a copy owned by edit otherwise.
+func (n *hamtNode[K, V]) editable(edit *editToken) *hamtNode[K, V] {
+	if edit != nil && n.edit == edit {
+		return n
+	}
+	return &hamtNode[K, V]{
+		dataMap: n.dataMap,
+		nodeMap: n.nodeMap,
+		data:    append([]MapElement[K, V](nil), n.data...),
+		nodes:   append([]*hamtNode[K, V](nil), n.nodes...),
+		edit:    edit,
+	}
+}
+
+// put returns the node with the given element and whether it was added
+// rather than replaced.
+func (n *hamtNode[K, V]) put(equal func(k1, k2 K) bool, edit *editToken, hash uint64, key K, val V, shift int) (*hamtNode[K, V], bool) {
+	if shift >= hamtDepth {
+		for i := range n.data {
+			if equal(n.data[i].Key, key) {
+				n = n.editable(edit)
+				n.data[i].Value = val
+				return n, false
+			}
+		}
+		n = n.editable(edit)
+		n.data = append(n.data, MapElement[K, V]{key, val, hash})
+		return n, true
+	}
+
+	bit := hamtBit(hash, shift)
+	switch {
+	case n.dataMap&bit != 0:
+		i := hamtIndex(n.dataMap, bit)
+		e := n.data[i]
+		if e.hash == hash && equal(e.Key, key) {
+			n = n.editable(edit)
+			n.data[i].Value = val
+			return n, false
+		}
+		// move the existing element and the new one to a sub-node
+		child := mergeHamtElements(edit, e, MapElement[K, V]{key, val, hash}, shift+hamtBits)
+		n = n.editable(edit)
+		n.data = deleteAt(n.data, i)
+		n.dataMap ^= bit
+		n.nodeMap |= bit
+		n.nodes = insertAt(n.nodes, hamtIndex(n.nodeMap, bit), child)
+		return n, true
+	case n.nodeMap&bit != 0:
+		i := hamtIndex(n.nodeMap, bit)
+		child, added := n.nodes[i].put(equal, edit, hash, key, val, shift+hamtBits)
+		if child != n.nodes[i] {
+			n = n.editable(edit)
+			n.nodes[i] = child
+		}
+		return n, added
+	default:
+		n = n.editable(edit)
+		n.dataMap |= bit
+		n.data = insertAt(n.data, hamtIndex(n.dataMap, bit), MapElement[K, V]{key, val, hash})
+		return n, true
+	}
+}
+
+// mergeHamtElements returns a node holding two elements with different keys.
+func mergeHamtElements[K any, V any](edit *editToken, e1, e2 MapElement[K, V], shift int) *hamtNode[K, V] {
+	if shift >= hamtDepth {
+		return &hamtNode[K, V]{data: []MapElement[K, V]{e1, e2}, edit: edit}
+	}
+	b1, b2 := hamtBit(e1.hash, shift), hamtBit(e2.hash, shift)
+	if b1 == b2 {
+		child := mergeHamtElements(edit, e1, e2, shift+hamtBits)
+		return &hamtNode[K, V]{nodeMap: b1, nodes: []*hamtNode[K, V]{child}, edit: edit}
+	}
+	if b1 > b2 {
+		e1, e2 = e2, e1
+	}
+	return &hamtNode[K, V]{dataMap: b1 | b2, data: []MapElement[K, V]{e1, e2}, edit: edit}
+}
+
+// remove returns the node without the given key and whether it was found.
+func (n *hamtNode[K, V]) remove(equal func(k1, k2 K) bool, edit *editToken, hash uint64, key K, shift int) (*hamtNode[K, V], bool) {
+	if shift >= hamtDepth {
+		for i := range n.data {
+			if equal(n.data[i].Key, key) {
+				n = n.editable(edit)
+				n.data = deleteAt(n.data, i)
+				return n, true
+			}
+		}
+		return n, false
+	}
+
+	bit := hamtBit(hash, shift)
+	switch {
+	case n.dataMap&bit != 0:
+		i := hamtIndex(n.dataMap, bit)
+		if e := &n.data[i]; e.hash != hash || !equal(e.Key, key) {
+			return n, false
+		}
+		n = n.editable(edit)
+		n.data = deleteAt(n.data, i)
+		n.dataMap ^= bit
+		return n, true
+	case n.nodeMap&bit != 0:
+		i := hamtIndex(n.nodeMap, bit)
+		child, removed := n.nodes[i].remove(equal, edit, hash, key, shift+hamtBits)
+		if !removed {
+			return n, false
+		}
+		n = n.editable(edit)
+		if len(child.nodes) == 0 && len(child.data) == 1 {
+			// canonical form: inline the last element of the sub-node
+			n.nodes = deleteAt(n.nodes, i)
+			n.nodeMap ^= bit
+			n.dataMap |= bit
+			n.data = insertAt(n.data, hamtIndex(n.dataMap, bit), child.data[0])
+		} else {
+			n.nodes[i] = child
+		}
+		return n, true
+	default:
+		return n, false
+	}
+}
+
+func (n *hamtNode[K, V]) all(yield func(K, V) bool) bool {
+	for i := range n.data {
+		if !yield(n.data[i].Key, n.data[i].Value) {
+			return false
+		}
+	}
+	for _, child := range n.nodes {
+		if !child.all(yield) {
+			return false
+		}
+	}
+	return true
+}
+
+// insertAt inserts v at index i of s, which is modified in place if it has
+// the capacity.
+func insertAt[T any](s []T, i int, v T) []T {
+	s = append(s, v)
+	copy(s[i+1:], s[i:])
+	s[i] = v
+	return s
+}
+
+// deleteAt removes the element at index i of s in place.
+func deleteAt[T any](s []T, i int) []T {
+	copy(s[i:], s[i+1:])
+	var zero T
+	s[len(s)-1] = zero
+	return s[:len(s)-1]
+}
@@ -0,0 +1,142 @@
+package genmap_test
+
+import (
+	"maps"
+	"math/rand"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+// checkPersistentMap checks that pm holds exactly the elements of ref.
+func checkPersistentMap(t *testing.T, pm *genmap.PersistentMap[int, int], ref map[int]int) {
+	t.Helper()
+	if pm.Len() != len(ref) {
+		t.Fatalf("expected %d elements, got %d", len(ref), pm.Len())
+	}
+	for k, want := range ref {
+		if got, ok := pm.Get(k); !ok || got != want {
+			t.Fatalf("Get(%d) = %d, %v, want %d", k, got, ok, want)
+		}
+	}
+	got := maps.Collect(pm.All())
+	if !maps.Equal(got, ref) {
+		t.Fatalf("All yields %v, want %v", got, ref)
+	}
+}
+
+func TestPersistentMap(t *testing.T) {
+	hashes := map[string]func(k int) uint64{
+		"good":       genmap.NewHasher[int](),
+		"collisions": func(k int) uint64 { return uint64(k % 7) },
+	}
+	for name, hash := range hashes {
+		t.Run(name, func(t *testing.T) {
+			r := rand.New(rand.NewSource(1))
+			pm := genmap.NewPersistentMap[int, int](genmap.Equal[int], hash)
+			ref := make(map[int]int)
+			type version struct {
+				pm  *genmap.PersistentMap[int, int]
+				ref map[int]int
+			}
+			var versions []version
+			for i := range 2000 {
+				k := r.Intn(300)
+				if r.Intn(3) == 0 {
+					pm = pm.Remove(k)
+					delete(ref, k)
+				} else {
+					pm = pm.Put(k, i)
+					ref[k] = i
+				}
+				if i%100 == 0 {
+					versions = append(versions, version{pm, maps.Clone(ref)})
+				}
+			}
+			checkPersistentMap(t, pm, ref)
+			// previous versions are unchanged
+			for _, v := range versions {
+				checkPersistentMap(t, v.pm, v.ref)
+			}
+		})
+	}
+}
+
+func TestPersistentMapRemoveAbsent(t *testing.T) {
+	pm := genmap.NewPersistentMap[int, int](genmap.Equal[int], genmap.NewHasher[int]()).Put(1, 1)
+	if pm.Remove(2) != pm {
+		t.Error("expected Remove of an absent key to return the same map")
+	}
+	var nilMap *genmap.PersistentMap[int, int]
+	if _, ok := nilMap.Get(1); ok || nilMap.Len() != 0 {
+		t.Error("expected empty nil map")
+	}
+}
+
+func TestTransientMap(t *testing.T) {
+	base := genmap.NewPersistentMap[int, int](genmap.Equal[int], func(k int) uint64 { return uint64(k % 50) })
+	for i := range 100 {
+		base = base.Put(i, i)
+	}
+	baseRef := maps.Collect(base.All())
+
+	tm := base.Transient()
+	ref := maps.Clone(baseRef)
+	for i := range 200 {
+		tm.Put(i, -i)
+		ref[i] = -i
+	}
+	for i := 0; i < 200; i += 3 {
+		if !tm.Remove(i) {
+			t.Fatalf("expected %d to be removed", i)
+		}
+		delete(ref, i)
+	}
+	if tm.Remove(0) {
+		t.Error("expected Remove of an absent key to fail")
+	}
+	if v, ok := tm.Get(4); !ok || v != -4 || tm.Len() != len(ref) {
+		t.Errorf("Get(4) = %d, %v", v, ok)
+	}
+	pm := tm.Persistent()
+	checkPersistentMap(t, pm, ref)
+	checkPersistentMap(t, base, baseRef)
+
+	// a new transient does not modify the previous versions
+	tm2 := pm.Transient()
+	tm2.Put(1, 1000)
+	tm2.Remove(2)
+	checkPersistentMap(t, pm, ref)
+	pm2 := tm2.Persistent()
+	if v, _ := pm2.Get(1); v != 1000 {
+		t.Errorf("expected 1000, got %d", v)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("expected a panic when using a transient after Persistent")
+		}
+	}()
+	tm.Put(1, 1)
+}
+
+func BenchmarkPersistentMapPut(b *testing.B) {
+	hash := genmap.NewHasher[int]()
+	for i := 0; i < b.N; i++ {
+		pm := genmap.NewPersistentMap[int, int](genmap.Equal[int], hash)
+		for k := range 1000 {
+			pm = pm.Put(k, k)
+		}
+	}
+}
+
+func BenchmarkTransientMapPut(b *testing.B) {
+	hash := genmap.NewHasher[int]()
+	for i := 0; i < b.N; i++ {
+		tm := genmap.NewPersistentMap[int, int](genmap.Equal[int], hash).Transient()
+		for k := range 1000 {
+			tm.Put(k, k)
+		}
+		tm.Persistent()
+	}
+}
@@ -0,0 +1,92 @@
+package genmap
+
+import "iter"
+
+// All returns an iterator over the key-value pairs of the map.
+//
+// The current element may be removed with Remove during the iteration, and
+// the iteration continues with the next element. Any other modification of
+// the map during the iteration may cause elements to be skipped or visited
+// twice.
+//
+// Example:
+//
+//	for k, v := range m.All() {
+//	    if v == 0 {
+//	        m.Remove(k)
+//	    }
+//	}
+func (m *Map[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		for elem := range m.elements(false) {
+			if !yield(elem.Key, elem.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys of the map.
+// See All for the modifications allowed during the iteration.
+func (m *Map[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for elem := range m.elements(false) {
+			if !yield(elem.Key) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values of the map.
+// See All for the modifications allowed during the iteration.
+func (m *Map[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for elem := range m.elements(false) {
+			if !yield(elem.Value) {
+				return
+			}
+		}
+	}
+}
+
+// Elements returns an iterator over pointers to the elements of the map,
+// allowing values to be modified in place. The key of an element must not be
+// modified; with the genmapdebug build tag, the iteration panics if it is.
+// The pointer is only valid until the next iteration step.
+// See All for the modifications allowed during the iteration.
+func (m *Map[K, V]) Elements() iter.Seq[*MapElement[K, V]] {
+	return m.elements(true)
+}
+
+// elements returns an iterator over pointers to the elements of the map.
+// Unless writable, the elements may be shared with a snapshot and must not be
+// modified.
+func (m *Map[K, V]) elements(writable bool) iter.Seq[*MapElement[K, V]] {
+	return func(yield func(*MapElement[K, V]) bool) {
+		if m == nil {
+			return
+		}
+		// prevent Remove from resizing the map while iterating
+		m.iterating++
+		defer func() { m.iterating-- }()
+
+		it := m.Iterator()
+		for it.Next() {
+			n := m.len
+			elem := it.cur(writable)
+			if !yield(elem) {
+				if debug && m.len == n {
+					newKeyGuard(m.hash, elem.hash).check(elem.Key)
+				}
+				return
+			}
+			if m.len == n-1 {
+				// the current element was removed, the next element
+				// (if any) is now at the current position
+				it.ready = false
+				it.mods = m.mods.snapshot()
+			}
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package genmap_test
+
+import (
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func TestMapAll(t *testing.T) {
+	forEachBackend(t, testMapAll)
+}
+
+func testMapAll(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[int, int](genmap.Equal[int], genmap.NewHasher[int](), append(opts, genmap.WithCapacity(1))...)
+	const n = 1000
+	for i := 0; i < n; i++ {
+		m.Put(i, i*2)
+	}
+
+	seen := make(map[int]bool, n)
+	for k, v := range m.All() {
+		if v != k*2 {
+			t.Errorf("expected value %d for key %d, got %d", k*2, k, v)
+		}
+		seen[k] = true
+	}
+	if len(seen) != n {
+		t.Errorf("expected %d keys, got %d", n, len(seen))
+	}
+
+	var nbKeys, sumValues int
+	for range m.Keys() {
+		nbKeys++
+	}
+	for v := range m.Values() {
+		sumValues += v
+	}
+	if nbKeys != n || sumValues != n*(n-1) {
+		t.Errorf("unexpected keys count %d or values sum %d", nbKeys, sumValues)
+	}
+
+	for elem := range m.Elements() {
+		elem.Value++
+	}
+	if v, ok := m.Get(10); !ok || v != 21 {
+		t.Errorf("expected value 21 for key 10, got %v", v)
+	}
+
+	// stop early
+	nbKeys = 0
+	for range m.Keys() {
+		nbKeys++
+		if nbKeys == 10 {
+			break
+		}
+	}
+	if nbKeys != 10 {
+		t.Errorf("expected 10 iterations, got %d", nbKeys)
+	}
+}
+
+func TestMapAllRemove(t *testing.T) {
+	forEachBackend(t, testMapAllRemove)
+}
+
+func testMapAllRemove(t *testing.T, opts ...genmap.MapOption) {
+	// a single bucket with a high load factor forces long chains
+	m := genmap.NewMapWithOptions[int, int](genmap.Equal[int], genmap.NewHasher[int](),
+		append(opts, genmap.WithCapacity(1), genmap.WithMaxLoadFactor(1000))...)
+	const n = 500
+	for i := 0; i < n; i++ {
+		m.Put(i, i)
+	}
+
+	visited := 0
+	for k := range m.Keys() {
+		visited++
+		if k%2 == 0 {
+			if _, ok := m.Remove(k); !ok {
+				t.Errorf("expected key %d to be removed", k)
+			}
+		}
+	}
+	if visited != n {
+		t.Errorf("expected %d iterations, got %d", n, visited)
+	}
+	if m.Len() != n/2 {
+		t.Errorf("expected length %d, got %d", n/2, m.Len())
+	}
+	for k := range m.Keys() {
+		if k%2 == 0 {
+			t.Errorf("unexpected removed key %d", k)
+		}
+	}
+
+	// the map resizes again once the iteration is over
+	for i := 0; i < n; i++ {
+		m.Remove(i)
+	}
+	if m.Len() != 0 {
+		t.Errorf("expected empty map, got %d elements", m.Len())
+	}
+}
+
+func TestNilMapAll(t *testing.T) {
+	var m *genmap.Map[int, int]
+	for range m.All() {
+		t.Errorf("unexpected element in nil map")
+	}
+}
+
+func BenchmarkMapAll(b *testing.B) {
+	m, _ := initMapAndKeys(100000, 64<<10)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for k, v := range m.All() {
+			_ = k
+			_ = v
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package genmap
+
+import "iter"
+
+// Set is a set of keys of any type, built on Map. Since its values are empty
+// structs, the elements of a Set only store their key and hash.
+// Set instance should be instantiated using the NewSet function.
+type Set[K any] struct {
+	m *Map[K, struct{}]
+}
+
+// NewSet returns a new instance of Set[K] with the given equality and hash
+// functions. The options configure the underlying Map.
+func NewSet[K any](equal func(k1, k2 K) bool, hash func(k K) uint64, opts ...MapOption) *Set[K] {
+	return &Set[K]{NewMapWithOptions[K, struct{}](equal, hash, opts...)}
+}
+
+// newSetLike returns a new empty set with the functions and configuration of s.
+func (s *Set[K]) newSetLike(capacity int) *Set[K] {
+	return &Set[K]{newMapLike[K, struct{}, struct{}](s.m, capacity)}
+}
+
+// Len returns the number of keys in the set.
+func (s *Set[K]) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.m.Len()
+}
+
+// Clear removes all keys from the set.
+func (s *Set[K]) Clear() {
+	s.m.Clear()
+}
+
+// Add adds the given key to the set.
+// It returns false if the key was already in the set.
+func (s *Set[K]) Add(key K) bool {
+	entry := s.m.Entry(key)
+	if entry.Exists() {
+		return false
+	}
+	entry.OrDefault()
+	return true
+}
+
+// Contains reports whether the given key is in the set.
+func (s *Set[K]) Contains(key K) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.m.Get(key)
+	return ok
+}
+
+// Remove removes the given key from the set.
+// It returns false if the key was not in the set.
+func (s *Set[K]) Remove(key K) bool {
+	_, ok := s.m.Remove(key)
+	return ok
+}
+
+// All returns an iterator over the keys of the set.
+// See Map.All for the modifications allowed during the iteration.
+func (s *Set[K]) All() iter.Seq[K] {
+	if s == nil {
+		return func(yield func(K) bool) {}
+	}
+	return s.m.Keys()
+}
+
+// Clone returns a copy of the set.
+func (s *Set[K]) Clone() *Set[K] {
+	c := s.newSetLike(s.Len())
+	for elem := range s.m.Elements() {
+		// the hash of the element is reused
+		c.m.insert(elem.hash, elem.Key)
+	}
+	return c
+}
+
+// The set algebra operations below return new sets using the functions and
+// configuration of the receiver. The other set is only queried with its own
+// functions, which should be consistent with the receiver's.
+
+// Union returns the set of the keys in s or other.
+func (s *Set[K]) Union(other *Set[K]) *Set[K] {
+	u := s.newSetLike(s.Len() + other.Len())
+	for elem := range s.m.Elements() {
+		u.m.insert(elem.hash, elem.Key)
+	}
+	for key := range other.All() {
+		u.Add(key)
+	}
+	return u
+}
+
+// Intersect returns the set of the keys in both s and other.
+func (s *Set[K]) Intersect(other *Set[K]) *Set[K] {
+	i := s.newSetLike(min(s.Len(), other.Len()))
+	if other.Len() < s.Len() {
+		// iterate over the smaller set
+		for key := range other.All() {
+			if s.Contains(key) {
+				i.Add(key)
+			}
+		}
+		return i
+	}
+	for elem := range s.m.Elements() {
+		if other.Contains(elem.Key) {
+			i.m.insert(elem.hash, elem.Key)
+		}
+	}
+	return i
+}
+
+// Difference returns the set of the keys in s but not in other.
+func (s *Set[K]) Difference(other *Set[K]) *Set[K] {
+	d := s.newSetLike(s.Len())
+	for elem := range s.m.Elements() {
+		if !other.Contains(elem.Key) {
+			d.m.insert(elem.hash, elem.Key)
+		}
+	}
+	return d
+}
+
+// SymmetricDifference returns the set of the keys in either s or other, but
+// not in both.
+func (s *Set[K]) SymmetricDifference(other *Set[K]) *Set[K] {
+	d := s.Difference(other)
+	for key := range other.All() {
+		if !s.Contains(key) {
+			d.Add(key)
+		}
+	}
+	return d
+}
+
+// IsSubset reports whether all the keys of s are in other.
+func (s *Set[K]) IsSubset(other *Set[K]) bool {
+	if s.Len() > other.Len() {
+		return false
+	}
+	for key := range s.All() {
+		if !other.Contains(key) {
+			return false
+		}
+	}
+	return true
+}
+
+// Equal reports whether s and other hold the same keys.
+func (s *Set[K]) Equal(other *Set[K]) bool {
+	return s.Len() == other.Len() && s.IsSubset(other)
+}
@@ -0,0 +1,92 @@
+package genmap_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func newSliceSet(keys ...[]int) *genmap.Set[[]int] {
+	s := genmap.NewSet[[]int](slices.Equal[[]int], genmap.NewAutoHasher[[]int](), genmap.WithCapacity(len(keys)))
+	for _, k := range keys {
+		s.Add(k)
+	}
+	return s
+}
+
+func setKeys(s *genmap.Set[[]int]) [][]int {
+	keys := slices.Collect(s.All())
+	slices.SortFunc(keys, slices.Compare[[]int])
+	return keys
+}
+
+func TestSet(t *testing.T) {
+	forEachBackend(t, testSet)
+}
+
+func testSet(t *testing.T, opts ...genmap.MapOption) {
+	s := genmap.NewSet[[]int](slices.Equal[[]int], genmap.NewAutoHasher[[]int](), opts...)
+	if !s.Add([]int{1, 2}) || !s.Add([]int{3}) {
+		t.Fatal("expected new keys to be added")
+	}
+	if s.Add([]int{1, 2}) {
+		t.Error("expected existing key not to be added")
+	}
+	if s.Len() != 2 {
+		t.Errorf("expected 2 keys, got %d", s.Len())
+	}
+	if !s.Contains([]int{1, 2}) || s.Contains([]int{2, 1}) {
+		t.Error("unexpected Contains result")
+	}
+	if !s.Remove([]int{3}) || s.Remove([]int{3}) {
+		t.Error("unexpected Remove result")
+	}
+	if got := setKeys(s); !slices.EqualFunc(got, [][]int{{1, 2}}, slices.Equal[[]int]) {
+		t.Errorf("unexpected keys %v", got)
+	}
+	s.Clear()
+	if s.Len() != 0 || s.Contains([]int{1, 2}) {
+		t.Error("expected empty set")
+	}
+
+	var nilSet *genmap.Set[[]int]
+	if nilSet.Len() != 0 || nilSet.Contains(nil) {
+		t.Error("expected empty nil set")
+	}
+	for range nilSet.All() {
+		t.Error("unexpected key in nil set")
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	a := newSliceSet([]int{1}, []int{2}, []int{1, 2})
+	b := newSliceSet([]int{2}, []int{1, 2}, []int{3}, []int{4})
+	tests := []struct {
+		name string
+		got  *genmap.Set[[]int]
+		want [][]int
+	}{
+		{"union", a.Union(b), [][]int{{1}, {1, 2}, {2}, {3}, {4}}},
+		{"intersect", a.Intersect(b), [][]int{{1, 2}, {2}}},
+		{"intersect smaller", b.Intersect(a), [][]int{{1, 2}, {2}}},
+		{"difference", a.Difference(b), [][]int{{1}}},
+		{"symmetric difference", a.SymmetricDifference(b), [][]int{{1}, {3}, {4}}},
+		{"clone", a.Clone(), [][]int{{1}, {1, 2}, {2}}},
+	}
+	for _, test := range tests {
+		if got := setKeys(test.got); !slices.EqualFunc(got, test.want, slices.Equal[[]int]) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+	}
+	if a.Len() != 3 || b.Len() != 4 {
+		t.Error("operands must not be modified")
+	}
+
+	if a.IsSubset(b) || !a.Intersect(b).IsSubset(b) || !a.IsSubset(a.Union(b)) {
+		t.Error("unexpected IsSubset result")
+	}
+	if a.Equal(b) || !a.Equal(a.Clone()) || !a.Union(b).Equal(b.Union(a)) {
+		t.Error("unexpected Equal result")
+	}
+}
@@ -0,0 +1,196 @@
+package genmap
+
+import (
+	"iter"
+	"slices"
+)
+
+// mapCOW tracks the buckets of a Map shared with snapshots, which must be
+// copied before being written to.
+type mapCOW struct {
+	// the bucket arrays (m.buckets and m.oldBuckets) themselves are shared
+	outerShared bool
+	// bit sets of the shared buckets of m.buckets and m.oldBuckets,
+	// nil if none is shared
+	shared    []uint64
+	oldShared []uint64
+}
+
+// MapSnapshot is a read-only view of a Map at the time Map.Snapshot was called.
+// It is not affected by later modifications of the map, and can be read from
+// other goroutines while the map is being modified.
+type MapSnapshot[K any, V any] struct {
+	equal      func(k1, k2 K) bool
+	hash       func(k K) uint64
+	buckets    [][]MapElement[K, V]
+	oldBuckets [][]MapElement[K, V]
+	swiss      *swissTable[K, V]
+	len        int
+}
+
+// Snapshot returns a read-only view of the current content of the map.
+//
+// With the Chaining backend, taking a snapshot does not copy the elements:
+// the buckets are shared with the snapshot, and copied on the first write to
+// each of them afterwards. With the OpenAddressing backend, the elements are
+// copied.
+//
+// Snapshot must not be called concurrently with other methods of the map, but
+// the snapshot can then be read concurrently with modifications of the map.
+// Like a modification, Snapshot invalidates the entries and iterators of the map.
+func (m *Map[K, V]) Snapshot() *MapSnapshot[K, V] {
+	m.mods.inc()
+	s := &MapSnapshot[K, V]{equal: m.equal, hash: m.hash, len: m.len}
+	if m.swiss != nil {
+		s.swiss = m.swiss.clone()
+		return s
+	}
+	s.buckets = m.buckets
+	s.oldBuckets = m.oldBuckets
+	m.cow = &mapCOW{
+		outerShared: true,
+		shared:      allSharedBits(len(m.buckets)),
+		oldShared:   allSharedBits(len(m.oldBuckets)),
+	}
+	return s
+}
+
+// allSharedBits returns a bit set of n shared buckets.
+func allSharedBits(n int) []uint64 {
+	if n == 0 {
+		return nil
+	}
+	bits := make([]uint64, (n+63)/64)
+	for i := range bits {
+		bits[i] = ^uint64(0)
+	}
+	return bits
+}
+
+// ownBuckets copies the bucket arrays if they are shared with a snapshot.
+// It must be called before locating a bucket to write to.
+func (m *Map[K, V]) ownBuckets() {
+	if m.cow == nil || !m.cow.outerShared {
+		return
+	}
+	m.buckets = slices.Clone(m.buckets)
+	if m.oldBuckets != nil {
+		m.oldBuckets = slices.Clone(m.oldBuckets)
+	}
+	m.cow.outerShared = false
+}
+
+// sharedBits returns the bit set of the shared buckets of the given bucket
+// array, which is either m.buckets or m.oldBuckets.
+func (m *Map[K, V]) sharedBits(buckets [][]MapElement[K, V]) []uint64 {
+	if len(m.oldBuckets) > 0 && &buckets[0] == &m.oldBuckets[0] {
+		return m.cow.oldShared
+	}
+	return m.cow.shared
+}
+
+// ownBucket copies the bucket id of buckets, which is either m.buckets or
+// m.oldBuckets, if it is shared with a snapshot, and returns it.
+func (m *Map[K, V]) ownBucket(buckets [][]MapElement[K, V], id uint64) []MapElement[K, V] {
+	if m.cow == nil {
+		return buckets[id]
+	}
+	bits := m.sharedBits(buckets)
+	if bits != nil && bits[id/64]&(1<<(id%64)) != 0 {
+		bits[id/64] &^= 1 << (id % 64)
+		buckets[id] = slices.Clone(buckets[id])
+	}
+	return buckets[id]
+}
+
+// isShared reports whether the bucket id of buckets is shared with a snapshot.
+func (m *Map[K, V]) isShared(buckets [][]MapElement[K, V], id uint64) bool {
+	if m.cow == nil {
+		return false
+	}
+	bits := m.sharedBits(buckets)
+	return bits != nil && bits[id/64]&(1<<(id%64)) != 0
+}
+
+// Len returns the number of elements in the snapshot.
+func (s *MapSnapshot[K, V]) Len() int {
+	if s == nil {
+		return 0
+	}
+	return s.len
+}
+
+// Get returns the value associated with the given key in the snapshot.
+func (s *MapSnapshot[K, V]) Get(key K) (V, bool) {
+	if s == nil {
+		return *new(V), false
+	}
+	hash := s.hash(key)
+	if s.swiss != nil {
+		if slot := s.swiss.find(hash, key); slot >= 0 {
+			return s.swiss.slots[slot].Value, true
+		}
+		return *new(V), false
+	}
+	bucket := s.buckets[hash%uint64(len(s.buckets))]
+	if s.oldBuckets != nil {
+		// same rule as Map.locate
+		if old := s.oldBuckets[hash%uint64(len(s.oldBuckets))]; len(old) > 0 {
+			bucket = old
+		}
+	}
+	for i := range bucket {
+		if bucket[i].hash == hash && s.equal(bucket[i].Key, key) {
+			return bucket[i].Value, true
+		}
+	}
+	return *new(V), false
+}
+
+// All returns an iterator over the key-value pairs of the snapshot.
+func (s *MapSnapshot[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if s == nil {
+			return
+		}
+		if s.swiss != nil {
+			for slot := range s.swiss.slots {
+				if s.swiss.isFull(slot) && !yield(s.swiss.slots[slot].Key, s.swiss.slots[slot].Value) {
+					return
+				}
+			}
+			return
+		}
+		for _, buckets := range [][][]MapElement[K, V]{s.oldBuckets, s.buckets} {
+			for _, bucket := range buckets {
+				for i := range bucket {
+					if !yield(bucket[i].Key, bucket[i].Value) {
+						return
+					}
+				}
+			}
+		}
+	}
+}
+
+// Keys returns an iterator over the keys of the snapshot.
+func (s *MapSnapshot[K, V]) Keys() iter.Seq[K] {
+	return func(yield func(K) bool) {
+		for k := range s.All() {
+			if !yield(k) {
+				return
+			}
+		}
+	}
+}
+
+// Values returns an iterator over the values of the snapshot.
+func (s *MapSnapshot[K, V]) Values() iter.Seq[V] {
+	return func(yield func(V) bool) {
+		for _, v := range s.All() {
+			if !yield(v) {
+				return
+			}
+		}
+	}
+}
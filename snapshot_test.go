@@ -0,0 +1,110 @@
+package genmap_test
+
+import (
+	"maps"
+	"sync"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func TestMapSnapshot(t *testing.T) {
+	forEachBackend(t, testMapSnapshot)
+}
+
+func testMapSnapshot(t *testing.T, opts ...genmap.MapOption) {
+	m := genmap.NewMapWithOptions[int, int](genmap.Equal[int], genmap.NewAutoHasher[int](), opts...)
+	for i := range 100 {
+		m.Put(i, i)
+	}
+	s := m.Snapshot()
+	want := maps.Collect(s.All())
+
+	// updates, insertions (with resizes), removals and in place modifications
+	for i := range 50 {
+		m.Put(i, -i)
+	}
+	for i := 100; i < 1000; i++ {
+		m.Put(i, i)
+	}
+	for i := 50; i < 80; i++ {
+		m.Remove(i)
+	}
+	m.Entry(90).AndModify(func(v *int) { *v = 0 })
+	for it := m.Iterator(); it.Next(); {
+		*it.CurValue() *= 2
+	}
+
+	if s.Len() != 100 {
+		t.Errorf("expected snapshot of 100 elements, got %d", s.Len())
+	}
+	if got := maps.Collect(s.All()); !maps.Equal(got, want) || len(got) != 100 {
+		t.Errorf("snapshot modified: %v", got)
+	}
+	for i := range 100 {
+		if v, ok := s.Get(i); !ok || v != i {
+			t.Errorf("expected %d in snapshot for %d, got %d, %v", i, i, v, ok)
+		}
+	}
+	if _, ok := s.Get(500); ok {
+		t.Error("unexpected key 500 in snapshot")
+	}
+	if v, _ := m.Get(10); v != -20 {
+		t.Errorf("expected -20 in map for 10, got %d", v)
+	}
+
+	// clearing the map does not affect the snapshot either
+	m.Clear()
+	if got := maps.Collect(s.All()); !maps.Equal(got, want) {
+		t.Errorf("snapshot modified by Clear: %v", got)
+	}
+}
+
+// TestMapSnapshotConcurrent reads snapshots while the map is modified, to be
+// run with the race detector.
+func TestMapSnapshotConcurrent(t *testing.T) {
+	m := genmap.NewMap[int, int](genmap.Equal[int], genmap.NewAutoHasher[int](), 16)
+	var wg sync.WaitGroup
+	for round := range 10 {
+		for i := range 200 {
+			m.Put(round*100+i, round)
+		}
+		s := m.Snapshot()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n := 0
+			for k, v := range s.All() {
+				if got, ok := s.Get(k); !ok || got != v {
+					t.Errorf("snapshot Get(%d) = %d, %v, want %d", k, got, ok, v)
+				}
+				n++
+			}
+			if n != s.Len() {
+				t.Errorf("snapshot yields %d elements, want %d", n, s.Len())
+			}
+		}()
+		for i := range 100 {
+			m.Remove(round*100 + i)
+		}
+	}
+	wg.Wait()
+}
+
+func TestMapSnapshotReadOnlyIteration(t *testing.T) {
+	m := genmap.NewMap[int, int](genmap.Equal[int], genmap.NewHasher[int](), 64)
+	for i := range 1000 {
+		m.Put(i, i)
+	}
+	// reading the map does not copy the buckets shared with snapshots
+	allocs := testing.AllocsPerRun(10, func() {
+		m.Snapshot()
+		n := 0
+		for range m.All() {
+			n++
+		}
+	})
+	if allocs > 10 {
+		t.Errorf("expected few allocations, got %v", allocs)
+	}
+}
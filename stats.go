@@ -0,0 +1,129 @@
+package genmap
+
+import "unsafe"
+
+// MapStats describes the internal state of a Map, as returned by Map.Stats.
+//
+// With the OpenAddressing backend, buckets are the groups of 8 slots the
+// elements are probed in, and there are no free slices nor allocation buffer.
+type MapStats struct {
+	// Len is the number of elements in the map.
+	Len int
+	// Buckets is the number of buckets.
+	Buckets int
+	// OldBuckets is the number of buckets not yet migrated while a resize is
+	// in progress, 0 otherwise.
+	OldBuckets int
+	// EmptyBuckets is the number of buckets (including old ones) holding no
+	// element.
+	EmptyBuckets int
+	// MaxChain is the number of elements of the largest bucket.
+	MaxChain int
+	// MeanChain is the average number of elements of the non-empty buckets,
+	// i.e. the average number of keys compared by a lookup of a present key
+	// is about (MeanChain+1)/2.
+	MeanChain float64
+	// ChainHistogram[n] is the number of buckets holding n elements.
+	ChainHistogram []int
+	// FreeSlices is the number of bucket slices kept for reuse.
+	FreeSlices int
+	// AllocBufferLeft is the number of elements left in the allocation buffer
+	// from which bucket slices are carved.
+	AllocBufferLeft int
+	// MemoryBytes is an estimate of the memory used by the map itself,
+	// excluding the memory referenced by the keys and values.
+	MemoryBytes int64
+}
+
+// Stats returns statistics about the internal state of the map, to check
+// that the bucket size suits the number of elements and the hash function.
+// It walks all the buckets, in O(number of buckets).
+func (m *Map[K, V]) Stats() MapStats {
+	var s MapStats
+	if m == nil {
+		return s
+	}
+	s.Len = m.len
+	elemSize := int64(unsafe.Sizeof(MapElement[K, V]{}))
+	sliceSize := int64(unsafe.Sizeof([]MapElement[K, V]{}))
+	s.MemoryBytes = int64(unsafe.Sizeof(*m))
+
+	addChain := func(n int) {
+		for len(s.ChainHistogram) <= n {
+			s.ChainHistogram = append(s.ChainHistogram, 0)
+		}
+		s.ChainHistogram[n]++
+		if n == 0 {
+			s.EmptyBuckets++
+		}
+		s.MaxChain = max(s.MaxChain, n)
+	}
+
+	if t := m.swiss; t != nil {
+		s.Buckets = len(t.ctrl)
+		for g := range t.ctrl {
+			n := 0
+			for i := 0; i < groupSize; i++ {
+				if t.isFull(g*groupSize + i) {
+					n++
+				}
+			}
+			addChain(n)
+		}
+		s.MemoryBytes += int64(unsafe.Sizeof(*t)) + int64(len(t.ctrl))*8 + int64(len(t.slots))*elemSize
+	} else {
+		s.Buckets = len(m.buckets)
+		s.OldBuckets = len(m.oldBuckets)
+		for _, buckets := range [][][]MapElement[K, V]{m.buckets, m.oldBuckets} {
+			for _, bucket := range buckets {
+				addChain(len(bucket))
+				s.MemoryBytes += sliceSize + int64(cap(bucket))*elemSize
+			}
+		}
+		s.FreeSlices = len(m.freeSlices)
+		s.AllocBufferLeft = len(m.allocBuffer)
+		for _, slice := range m.freeSlices {
+			s.MemoryBytes += sliceSize + int64(cap(slice))*elemSize
+		}
+		// only the part not handed out yet, the rest is counted in the buckets
+		s.MemoryBytes += int64(len(m.allocBuffer)) * elemSize
+	}
+
+	s.setMeanChain()
+	return s
+}
+
+func (s *MapStats) setMeanChain() {
+	if nonEmpty := s.Buckets + s.OldBuckets - s.EmptyBuckets; nonEmpty > 0 {
+		s.MeanChain = float64(s.Len) / float64(nonEmpty)
+	}
+}
+
+// Stats returns the statistics of all the shards of the map added together.
+// Each shard is locked in turn, so concurrent modifications may or may not be
+// accounted for.
+func (cm *ConcurrentMap[K, V]) Stats() MapStats {
+	var s MapStats
+	for i := range cm.shards {
+		shard := &cm.shards[i]
+		shard.RLock()
+		ss := shard.m.Stats()
+		shard.RUnlock()
+		s.Len += ss.Len
+		s.Buckets += ss.Buckets
+		s.OldBuckets += ss.OldBuckets
+		s.EmptyBuckets += ss.EmptyBuckets
+		s.MaxChain = max(s.MaxChain, ss.MaxChain)
+		for len(s.ChainHistogram) < len(ss.ChainHistogram) {
+			s.ChainHistogram = append(s.ChainHistogram, 0)
+		}
+		for n, count := range ss.ChainHistogram {
+			s.ChainHistogram[n] += count
+		}
+		s.FreeSlices += ss.FreeSlices
+		s.AllocBufferLeft += ss.AllocBufferLeft
+		s.MemoryBytes += ss.MemoryBytes
+	}
+	s.setMeanChain()
+	return s
+}
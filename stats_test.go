@@ -0,0 +1,77 @@
+package genmap_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/ronanh/genmap"
+)
+
+func TestMapStats(t *testing.T) {
+	forEachBackend(t, testMapStats)
+}
+
+func testMapStats(t *testing.T, opts ...genmap.MapOption) {
+	hash := genmap.NewHasher[int]()
+	var m *genmap.Map[int, int]
+	// no resize with the given capacity: all the allocations are still in use
+	allocated := allocatedBytes(func() {
+		m = genmap.NewMapWithOptions[int, int](genmap.Equal[int], hash, append(opts, genmap.WithCapacity(1000))...)
+		for i := range 1000 {
+			m.Put(i, i)
+		}
+	})
+	s := m.Stats()
+	if s.Len != 1000 {
+		t.Errorf("expected 1000 elements, got %d", s.Len)
+	}
+	buckets, elems := 0, 0
+	for n, count := range s.ChainHistogram {
+		buckets += count
+		elems += n * count
+	}
+	if buckets != s.Buckets+s.OldBuckets {
+		t.Errorf("histogram holds %d buckets, want %d", buckets, s.Buckets+s.OldBuckets)
+	}
+	if elems != s.Len {
+		t.Errorf("histogram holds %d elements, want %d", elems, s.Len)
+	}
+	if s.EmptyBuckets != s.ChainHistogram[0] || s.MaxChain != len(s.ChainHistogram)-1 {
+		t.Errorf("inconsistent stats: %+v", s)
+	}
+	if s.MeanChain < 1 || s.MeanChain > float64(s.MaxChain) {
+		t.Errorf("unexpected mean chain length %v", s.MeanChain)
+	}
+	if s.MemoryBytes < int64(allocated)*8/10 || s.MemoryBytes > int64(allocated)*11/10 {
+		t.Errorf("memory estimate %d, want about %d", s.MemoryBytes, allocated)
+	}
+
+	var nilMap *genmap.Map[int, int]
+	if s := nilMap.Stats(); s.Len != 0 || s.Buckets != 0 {
+		t.Errorf("unexpected stats for a nil map: %+v", s)
+	}
+}
+
+func TestMapStatsPoorHash(t *testing.T) {
+	m := genmap.NewMap[int, int](genmap.Equal[int], func(k int) uint64 { return uint64(k % 4) }, 64)
+	for i := range 100 {
+		m.Put(i, i)
+	}
+	s := m.Stats()
+	if s.MaxChain != 25 || s.EmptyBuckets != s.Buckets-4 {
+		t.Errorf("expected 4 buckets of 25 elements, got %+v", s)
+	}
+	if s.FreeSlices == 0 && s.AllocBufferLeft == 0 {
+		t.Errorf("expected free slices or allocation buffer: %+v", s)
+	}
+}
+
+// allocatedBytes returns the number of bytes allocated by f.
+func allocatedBytes(f func()) uint64 {
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	f()
+	runtime.ReadMemStats(&after)
+	return after.TotalAlloc - before.TotalAlloc
+}
@@ -0,0 +1,218 @@
+package genmap
+
+import (
+	"math/bits"
+	"slices"
+)
+
+// Open addressing (Swiss table style) backend.
+//
+// Slots are organized in groups of 8. Each slot has a control byte which is
+// either ctrlEmpty, ctrlDeleted (tombstone), or the 7 low bits of the hash of
+// the element it holds. The control bytes of a group are packed in a single
+// uint64 word, so that a whole group can be matched at once with SWAR tricks.
+// The remaining bits of the hash select the group where probing starts.
+
+const (
+	groupSize = 8
+
+	ctrlEmpty   uint8 = 0x80
+	ctrlDeleted uint8 = 0xfe
+
+	ctrlGroupEmpty uint64 = 0x8080808080808080
+	lsbs           uint64 = 0x0101010101010101
+	msbs           uint64 = 0x8080808080808080
+)
+
+// matchH2 returns a mask with the high bit set for each byte of w equal to h2.
+// It may report false positives for bytes following a match, which are
+// filtered out by comparing the full hash.
+func matchH2(w uint64, h2 uint8) uint64 {
+	x := w ^ (lsbs * uint64(h2))
+	return (x - lsbs) &^ x & msbs
+}
+
+// matchEmpty returns a mask with the high bit set for each empty byte of w.
+func matchEmpty(w uint64) uint64 {
+	return w &^ (w << 6) & msbs
+}
+
+// matchEmptyOrDeleted returns a mask with the high bit set for each empty or
+// deleted byte of w.
+func matchEmptyOrDeleted(w uint64) uint64 {
+	return w & msbs
+}
+
+// swissTable holds the elements of a Map using the open addressing backend.
+type swissTable[K any, V any] struct {
+	equal      func(k1, k2 K) bool
+	ctrl       []uint64 // control bytes, one word per group
+	slots      []MapElement[K, V]
+	growthLeft int // number of empty slots that can be used before rehashing
+	minGroups  int
+}
+
+func newSwissTable[K any, V any](equal func(k1, k2 K) bool, capacity int) *swissTable[K, V] {
+	groups := groupsFor(capacity)
+	t := &swissTable[K, V]{equal: equal, minGroups: groups}
+	t.reset(groups)
+	return t
+}
+
+// groupsFor returns the number of groups (a power of 2) needed to hold
+// capacity elements without rehashing.
+func groupsFor(capacity int) int {
+	// at most 7/8 of the slots are used
+	groups := (capacity*8/7 + groupSize - 1) / groupSize
+	if groups <= 1 {
+		return 1
+	}
+	return 1 << bits.Len(uint(groups-1))
+}
+
+// reset allocates a new empty table with the given number of groups.
+func (t *swissTable[K, V]) reset(groups int) {
+	t.ctrl = make([]uint64, groups)
+	for i := range t.ctrl {
+		t.ctrl[i] = ctrlGroupEmpty
+	}
+	t.slots = make([]MapElement[K, V], groups*groupSize)
+	t.growthLeft = maxSlotsUsed(groups)
+}
+
+// maxSlotsUsed returns the number of slots that can be full or deleted for
+// the given number of groups.
+func maxSlotsUsed(groups int) int {
+	return groups * groupSize * 7 / 8
+}
+
+func (t *swissTable[K, V]) ctrlByte(slot int) uint8 {
+	return uint8(t.ctrl[slot/groupSize] >> (8 * (slot % groupSize)))
+}
+
+func (t *swissTable[K, V]) setCtrlByte(slot int, b uint8) {
+	shift := 8 * (slot % groupSize)
+	w := &t.ctrl[slot/groupSize]
+	*w = *w&^(0xff<<shift) | uint64(b)<<shift
+}
+
+// isFull reports whether the slot holds an element.
+func (t *swissTable[K, V]) isFull(slot int) bool {
+	return t.ctrlByte(slot)&ctrlEmpty == 0
+}
+
+// find returns the slot holding the element with the given hash and key,
+// or -1 if there is none.
+func (t *swissTable[K, V]) find(hash uint64, key K) int {
+	h2 := uint8(hash & 0x7f)
+	mask := uint64(len(t.ctrl) - 1)
+	g := (hash >> 7) & mask
+	for step := uint64(1); ; step++ {
+		w := t.ctrl[g]
+		for match := matchH2(w, h2); match != 0; match &= match - 1 {
+			slot := int(g)*groupSize + bits.TrailingZeros64(match)/8
+			if elem := &t.slots[slot]; elem.hash == hash && t.equal(elem.Key, key) {
+				return slot
+			}
+		}
+		if matchEmpty(w) != 0 {
+			return -1
+		}
+		// triangular probing visits every group when their number is a power of 2
+		g = (g + step) & mask
+	}
+}
+
+// findInsertSlot returns the first empty or deleted slot of the probe
+// sequence of the given hash.
+func (t *swissTable[K, V]) findInsertSlot(hash uint64) int {
+	mask := uint64(len(t.ctrl) - 1)
+	g := (hash >> 7) & mask
+	for step := uint64(1); ; step++ {
+		if match := matchEmptyOrDeleted(t.ctrl[g]); match != 0 {
+			return int(g)*groupSize + bits.TrailingZeros64(match)/8
+		}
+		g = (g + step) & mask
+	}
+}
+
+// insert adds a new element for key, which must not already be in the table,
+// and returns a pointer to it. n is the number of elements in the table
+// before the insertion.
+func (t *swissTable[K, V]) insert(hash uint64, key K, n int) *MapElement[K, V] {
+	slot := t.findInsertSlot(hash)
+	if t.growthLeft == 0 && t.ctrlByte(slot) == ctrlEmpty {
+		groups := len(t.ctrl)
+		if n > maxSlotsUsed(groups)/2 {
+			groups *= 2
+		}
+		// otherwise the table is mostly tombstones: rehash in place
+		t.rehash(groups)
+		slot = t.findInsertSlot(hash)
+	}
+	if t.ctrlByte(slot) == ctrlEmpty {
+		t.growthLeft--
+	}
+	t.setCtrlByte(slot, uint8(hash&0x7f))
+	elem := &t.slots[slot]
+	elem.hash = hash
+	elem.Key = key
+	return elem
+}
+
+// removeAt removes the element of the given slot and returns it.
+func (t *swissTable[K, V]) removeAt(slot int) (elem MapElement[K, V]) {
+	elem = t.slots[slot]
+	t.slots[slot] = MapElement[K, V]{}
+	// probing stops at a group with an empty slot, so the slot can be
+	// marked empty rather than deleted if its group has one already
+	if matchEmpty(t.ctrl[slot/groupSize]) != 0 {
+		t.setCtrlByte(slot, ctrlEmpty)
+		t.growthLeft++
+	} else {
+		t.setCtrlByte(slot, ctrlDeleted)
+	}
+	return
+}
+
+// shrink halves the table if it holds few elements (n) for its size.
+func (t *swissTable[K, V]) shrink(n int) {
+	if groups := len(t.ctrl); groups > t.minGroups && n < maxSlotsUsed(groups)/8 {
+		t.rehash(groups / 2)
+	}
+}
+
+// rehash moves all the elements to a new table with the given number of groups.
+func (t *swissTable[K, V]) rehash(groups int) {
+	oldCtrl, oldSlots := t.ctrl, t.slots
+	t.reset(groups)
+	for slot := range oldSlots {
+		if oldCtrl[slot/groupSize]>>(8*(slot%groupSize))&uint64(ctrlEmpty) != 0 {
+			continue
+		}
+		hash := oldSlots[slot].hash
+		newSlot := t.findInsertSlot(hash)
+		t.setCtrlByte(newSlot, uint8(hash&0x7f))
+		t.slots[newSlot] = oldSlots[slot]
+		t.growthLeft--
+	}
+}
+
+// clone returns a copy of the table.
+func (t *swissTable[K, V]) clone() *swissTable[K, V] {
+	c := *t
+	c.ctrl = slices.Clone(t.ctrl)
+	c.slots = slices.Clone(t.slots)
+	return &c
+}
+
+// clear removes all elements, keeping the current size.
+func (t *swissTable[K, V]) clear() {
+	for i := range t.ctrl {
+		t.ctrl[i] = ctrlGroupEmpty
+	}
+	for i := range t.slots {
+		t.slots[i] = MapElement[K, V]{}
+	}
+	t.growthLeft = maxSlotsUsed(len(t.ctrl))
+}
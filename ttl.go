@@ -0,0 +1,187 @@
+package genmap
+
+import (
+	"iter"
+	"time"
+)
+
+// ttlValue is the value stored in the Map underlying a TTLMap.
+type ttlValue[V any] struct {
+	value  V
+	expiry int64 // unix nanoseconds, 0 if the element never expires
+}
+
+// TTLMap is a Map whose elements can expire.
+// Expired elements are invisible to Get and iteration. They are reclaimed
+// lazily when accessed, or explicitly with Sweep; no background goroutine
+// is involved.
+// TTLMap instance should be instantiated using the NewTTLMap function.
+type TTLMap[K any, V any] struct {
+	m          *Map[K, ttlValue[V]]
+	defaultTTL time.Duration
+	now        func() time.Time
+}
+
+// NewTTLMap returns a new instance of TTLMap[K, V] with the given equality and
+// hash functions.
+// defaultTTL is the time to live of the elements inserted with Put and Upsert,
+// 0 for no expiry. clock returns the current time, time.Now if nil.
+// The options configure the underlying Map.
+func NewTTLMap[K any, V any](equal func(k1, k2 K) bool, hash func(k K) uint64, defaultTTL time.Duration, clock func() time.Time, opts ...MapOption) *TTLMap[K, V] {
+	if clock == nil {
+		clock = time.Now
+	}
+	return &TTLMap[K, V]{
+		m:          NewMapWithOptions[K, ttlValue[V]](equal, hash, opts...),
+		defaultTTL: defaultTTL,
+		now:        clock,
+	}
+}
+
+// expiry returns the expiry of an element inserted now with the given ttl.
+func (tm *TTLMap[K, V]) expiry(ttl time.Duration) int64 {
+	if ttl <= 0 {
+		return 0
+	}
+	return tm.now().Add(ttl).UnixNano()
+}
+
+func expired(expiry, now int64) bool {
+	return expiry != 0 && expiry <= now
+}
+
+// Len returns the number of elements in the map, including the expired
+// elements not yet reclaimed. Call Sweep first for an exact count.
+func (tm *TTLMap[K, V]) Len() int {
+	if tm == nil {
+		return 0
+	}
+	return tm.m.Len()
+}
+
+// Clear removes all elements from the map.
+func (tm *TTLMap[K, V]) Clear() {
+	tm.m.Clear()
+}
+
+// Get returns the value associated with the given key, unless it expired.
+func (tm *TTLMap[K, V]) Get(key K) (V, bool) {
+	if tm == nil {
+		return *new(V), false
+	}
+	entry := tm.m.Entry(key)
+	if !entry.Exists() {
+		return *new(V), false
+	}
+	if expired(entry.elem.Value.expiry, tm.now().UnixNano()) {
+		entry.Remove()
+		return *new(V), false
+	}
+	return entry.elem.Value.value, true
+}
+
+// ExpiresAt returns the expiry time of the given key, the zero time if it
+// never expires. It returns false if the key is absent or expired.
+func (tm *TTLMap[K, V]) ExpiresAt(key K) (time.Time, bool) {
+	v, ok := tm.m.Get(key)
+	if !ok || expired(v.expiry, tm.now().UnixNano()) {
+		return time.Time{}, false
+	}
+	if v.expiry == 0 {
+		return time.Time{}, true
+	}
+	return time.Unix(0, v.expiry), true
+}
+
+// Put inserts the given key-value pair into the map, expiring after the
+// default time to live.
+func (tm *TTLMap[K, V]) Put(key K, val V) {
+	tm.PutWithTTL(key, val, tm.defaultTTL)
+}
+
+// PutWithTTL inserts the given key-value pair into the map, expiring after
+// ttl, or never if ttl is 0.
+func (tm *TTLMap[K, V]) PutWithTTL(key K, val V, ttl time.Duration) {
+	tm.m.Put(key, ttlValue[V]{val, tm.expiry(ttl)})
+}
+
+// Upsert inserts or modifies the given entry into the map.
+// The update function is called with the current value or the new one.
+// An expired element is considered absent. A new element expires after the
+// default time to live, an existing element keeps its expiry.
+func (tm *TTLMap[K, V]) Upsert(key K, update func(elem *MapElement[K, V], exists bool)) {
+	tm.upsert(key, update, false, 0)
+}
+
+// UpsertWithTTL is like Upsert, but the element expires after ttl (or never
+// if ttl is 0), whether it is new or not.
+func (tm *TTLMap[K, V]) UpsertWithTTL(key K, ttl time.Duration, update func(elem *MapElement[K, V], exists bool)) {
+	tm.upsert(key, update, true, ttl)
+}
+
+func (tm *TTLMap[K, V]) upsert(key K, update func(elem *MapElement[K, V], exists bool), setTTL bool, ttl time.Duration) {
+	now := tm.now()
+	tm.m.Upsert(key, func(elem *MapElement[K, ttlValue[V]], exists bool) {
+		if exists && expired(elem.Value.expiry, now.UnixNano()) {
+			elem.Value = ttlValue[V]{}
+			exists = false
+		}
+		if !exists && !setTTL {
+			setTTL, ttl = true, tm.defaultTTL
+		}
+		if setTTL {
+			elem.Value.expiry = 0
+			if ttl > 0 {
+				elem.Value.expiry = now.Add(ttl).UnixNano()
+			}
+		}
+		e := MapElement[K, V]{Key: elem.Key, Value: elem.Value.value}
+		update(&e, exists)
+		elem.Value.value = e.Value
+	})
+}
+
+// Remove removes the given key from the map and returns it.
+// It returns false if the key is absent or expired.
+func (tm *TTLMap[K, V]) Remove(key K) (MapElement[K, V], bool) {
+	removed, ok := tm.m.Remove(key)
+	if !ok || expired(removed.Value.expiry, tm.now().UnixNano()) {
+		return MapElement[K, V]{}, false
+	}
+	return MapElement[K, V]{Key: removed.Key, Value: removed.Value.value}, true
+}
+
+// Sweep removes the elements expired at the given time and returns their number.
+func (tm *TTLMap[K, V]) Sweep(now time.Time) int {
+	n := 0
+	nowNano := now.UnixNano()
+	it := tm.m.Iterator()
+	for it.Next() {
+		if expired(it.Cur().Value.expiry, nowNano) {
+			it.Remove()
+			n++
+		}
+	}
+	return n
+}
+
+// All returns an iterator over the key-value pairs of the map that are not
+// expired. Expired elements met during the iteration are reclaimed.
+// See Map.All for the modifications allowed during the iteration.
+func (tm *TTLMap[K, V]) All() iter.Seq2[K, V] {
+	return func(yield func(K, V) bool) {
+		if tm == nil {
+			return
+		}
+		now := tm.now().UnixNano()
+		for elem := range tm.m.Elements() {
+			if expired(elem.Value.expiry, now) {
+				tm.m.Remove(elem.Key)
+				continue
+			}
+			if !yield(elem.Key, elem.Value.value) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package genmap_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ronanh/genmap"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+func TestTTLMap(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(1000, 0)}
+	m := genmap.NewTTLMap[string, int](genmap.Equal[string], genmap.NewHasher[string](), time.Minute, clock.Now)
+
+	m.Put("a", 1)                   // expires after 1 minute
+	m.PutWithTTL("b", 2, time.Hour) // expires after 1 hour
+	m.PutWithTTL("c", 3, 0)         // never expires
+	m.Upsert("d", func(elem *genmap.MapElement[string, int], exists bool) {
+		elem.Value = 4 // expires after 1 minute
+	})
+	if m.Len() != 4 {
+		t.Errorf("expected length 4, got %d", m.Len())
+	}
+	if at, ok := m.ExpiresAt("a"); !ok || !at.Equal(clock.now.Add(time.Minute)) {
+		t.Errorf("unexpected expiry %v", at)
+	}
+	if at, ok := m.ExpiresAt("c"); !ok || !at.IsZero() {
+		t.Errorf("expected no expiry, got %v", at)
+	}
+
+	clock.now = clock.now.Add(30 * time.Second)
+	m.Upsert("d", func(elem *genmap.MapElement[string, int], exists bool) {
+		if !exists {
+			t.Errorf("expected existing key d")
+		}
+		elem.Value++ // keeps its expiry
+	})
+	m.UpsertWithTTL("a", time.Minute, func(elem *genmap.MapElement[string, int], exists bool) {
+		elem.Value += 10 // expiry extended
+	})
+
+	clock.now = clock.now.Add(45 * time.Second)
+	if _, ok := m.Get("d"); ok {
+		t.Errorf("expected key d to be expired")
+	}
+	if m.Len() != 3 {
+		t.Errorf("expected expired key d to be reclaimed, got length %d", m.Len())
+	}
+	if v, ok := m.Get("a"); !ok || v != 11 {
+		t.Errorf("expected value 11 for key a, got %v", v)
+	}
+
+	clock.now = clock.now.Add(time.Minute)
+	sum := 0
+	for k, v := range m.All() {
+		if k == "a" {
+			t.Errorf("unexpected expired key a")
+		}
+		sum += v
+	}
+	if sum != 5 || m.Len() != 2 {
+		t.Errorf("unexpected sum %d or length %d", sum, m.Len())
+	}
+
+	// an expired key is absent for Upsert and Remove
+	m.PutWithTTL("e", 5, time.Second)
+	m.PutWithTTL("f", 6, time.Second)
+	clock.now = clock.now.Add(time.Second)
+	m.Upsert("e", func(elem *genmap.MapElement[string, int], exists bool) {
+		if exists || elem.Value != 0 {
+			t.Errorf("unexpected expired key e")
+		}
+		elem.Value = 7
+	})
+	if _, ok := m.Remove("f"); ok {
+		t.Errorf("unexpected removal of expired key f")
+	}
+
+	if n := m.Sweep(clock.now.Add(2 * time.Hour)); n != 2 {
+		t.Errorf("expected 2 expired keys, got %d", n)
+	}
+	if v, ok := m.Get("c"); !ok || v != 3 || m.Len() != 1 {
+		t.Errorf("expected only key c to remain, got %v, length %d", v, m.Len())
+	}
+}